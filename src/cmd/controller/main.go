@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/wizenheimer/cascade/interface/controller"
+	"github.com/wizenheimer/cascade/internal/config"
+	"github.com/wizenheimer/cascade/internal/tracing"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Create a logger
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+
+	// Setup tracing
+	shutdown, err := tracing.Setup(context.Background(), "cascade-controller")
+	if err != nil {
+		logger.Fatal("failed to setup tracing", zap.Error(err))
+	}
+	defer shutdown(context.Background())
+
+	workers, err := strconv.Atoi(config.GetEnv("CONTROLLER_WORKERS", config.CONTROLLER_WORKERS))
+	if err != nil {
+		logger.Fatal("failed to parse CONTROLLER_WORKERS", zap.Error(err))
+	}
+
+	// Act upon the cluster the controller runs in, unless told otherwise
+	cc := &k8x.ClusterConfig{
+		Origin:      config.GetEnv("ORIGIN", config.CONTROLLER_ORIGIN),
+		Kubeconfig:  os.Getenv("KUBECONFIG"),
+		Healthcheck: config.GetEnv("HEALTH_CHECK_PORT", config.HEALTH_CHECK_PORT),
+	}
+
+	// Create a Controller for the Custom Resources
+	ctrl, err := controller.NewController(cc, os.Getenv("WATCH_NAMESPACE"), logger)
+	if err != nil {
+		logger.Fatal("failed to initialize Controller", zap.Error(err))
+	}
+
+	// Pods are stopped with SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := ctrl.Run(ctx, workers); err != nil {
+		logger.Fatal(err.Error())
+	}
+}
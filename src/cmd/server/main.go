@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+
 	"github.com/wizenheimer/cascade/interface/rest"
+	"github.com/wizenheimer/cascade/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -12,7 +15,14 @@ func main() {
 		panic(err)
 	}
 
+	// Setup tracing
+	shutdown, err := tracing.Setup(context.Background(), "cascade-server")
+	if err != nil {
+		logger.Fatal("failed to setup tracing", zap.Error(err))
+	}
+	defer shutdown(context.Background())
+
 	// Create a New RESTful API Service
-	server := rest.NewAPIServer(logger)
+	server := rest.NewAPIServer(logger, rest.ServerOptions{})
 	server.Serve()
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/parser"
+)
+
+// Client of a Cascade server, used by the remote commands
+type remoteClient struct {
+	server string
+	token  string
+	// Team of the context, if any
+	team string
+	http *http.Client
+}
+
+// Flags selecting the server remote commands talk to, along with the credentials used for it
+func remoteFlags(flags ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{Name: "context", Category: "Remote", Usage: "Context of the config file to use, defaults to the current context", EnvVars: []string{"CASCADE_CONTEXT"}},
+		&cli.StringFlag{Name: "server", Category: "Remote", Usage: "Address of the server, overriding the one of the context", EnvVars: []string{"CASCADE_SERVER"}},
+		&cli.StringFlag{Name: "token", Category: "Remote", Usage: "Session token or API Key, overriding the one of the context", EnvVars: []string{"CASCADE_TOKEN"}},
+	}, flags...)
+}
+
+// Returns a client for the server selected by the flags, falling back onto the context
+func newRemoteClient(c *cli.Context) (*remoteClient, error) {
+	client := &remoteClient{
+		server: c.String("server"),
+		token:  c.String("token"),
+		http:   &http.Client{},
+	}
+
+	if client.server == "" || c.IsSet("context") {
+		cfg, err := loadRemoteConfig()
+		if err != nil {
+			return nil, cli.Exit(err.Error(), exitUsage)
+		}
+		rc, err := cfg.context(c.String("context"))
+		if err != nil {
+			return nil, cli.Exit(err.Error(), exitUsage)
+		}
+		if client.server == "" {
+			client.server = rc.Server
+		}
+		if client.token == "" {
+			client.token = rc.Token
+		}
+		client.team = rc.Team
+	}
+
+	client.server = strings.TrimSuffix(client.server, "/")
+	return client, nil
+}
+
+// Error answered by the server
+type remoteError struct {
+	Status  int
+	Message string
+}
+
+func (e *remoteError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server answered %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("server answered %d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// Sends the request, responses outside of 2xx are turned into a *remoteError
+func (client *remoteClient) do(ctx context.Context, method string, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	u := client.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+client.token)
+	}
+
+	resp, err := client.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, readRemoteError(resp)
+	}
+	return resp, nil
+}
+
+// Handlers answer errors as a JSON string, a validation result or an echo error
+func readRemoteError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	rerr := &remoteError{Status: resp.StatusCode}
+
+	var message string
+	var result struct {
+		Message string                  `json:"message"`
+		Errors  parser.ValidationErrors `json:"errors"`
+	}
+	switch {
+	case json.Unmarshal(data, &message) == nil:
+		rerr.Message = message
+	case json.Unmarshal(data, &result) == nil && len(result.Errors) > 0:
+		rerr.Message = result.Errors.Error()
+	case json.Unmarshal(data, &result) == nil && result.Message != "":
+		rerr.Message = result.Message
+	default:
+		rerr.Message = strings.TrimSpace(string(data))
+	}
+	return rerr
+}
+
+// Decodes the answer onto out, unless nil
+func decodeAnswer(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (client *remoteClient) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	resp, err := client.do(ctx, http.MethodGet, path, query, nil, "")
+	if err != nil {
+		return err
+	}
+	return decodeAnswer(resp, out)
+}
+
+// Sends the form url encoded
+func (client *remoteClient) send(ctx context.Context, method string, path string, form url.Values, out interface{}) error {
+	resp, err := client.do(ctx, method, path, nil, strings.NewReader(form.Encode()), "application/x-www-form-urlencoded")
+	if err != nil {
+		return err
+	}
+	return decodeAnswer(resp, out)
+}
+
+// Sends the file as a multipart form, alongside the form values
+func (client *remoteClient) sendFile(ctx context.Context, method string, path string, field string, name string, data []byte, form url.Values, out interface{}) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for key, values := range form {
+		for _, value := range values {
+			if err := w.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	part, err := w.CreateFormFile(field, name)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	resp, err := client.do(ctx, method, path, nil, &body, w.FormDataContentType())
+	if err != nil {
+		return err
+	}
+	return decodeAnswer(resp, out)
+}
+
+// Opens a stream of server sent events, the caller reads it using readEvents and closes it
+func (client *remoteClient) stream(ctx context.Context, method string, path string, form url.Values) (io.ReadCloser, error) {
+	var body io.Reader
+	contentType := ""
+	if method != http.MethodGet {
+		body = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	}
+	resp, err := client.do(ctx, method, path, nil, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Hands every log entry of the event stream over, until the stream ends
+func readEvents(stream io.Reader, handle func(entry log.LogEntry)) error {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var entry log.LogEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue
+		}
+		handle(entry)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Servers the CLI talks to in remote mode, one of them is current
+type remoteConfig struct {
+	CurrentContext string          `yaml:"current-context"`
+	Contexts       []remoteContext `yaml:"contexts"`
+}
+
+// Server along with the credentials used for it
+type remoteContext struct {
+	Name   string `yaml:"name"`
+	Server string `yaml:"server"`
+	// Session token or API Key
+	Token string `yaml:"token,omitempty"`
+	// Team scenarios are pushed onto and listed from, unless passed otherwise
+	Team string `yaml:"team,omitempty"`
+}
+
+// Returns the path of the config file, ~/.cascade/config.yaml unless CASCADE_CONFIG is set
+func remoteConfigPath() string {
+	if path := os.Getenv("CASCADE_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".cascade", "config.yaml")
+}
+
+// Reads the config file, a missing file yields an empty config
+func loadRemoteConfig() (*remoteConfig, error) {
+	var cfg remoteConfig
+	data, err := os.ReadFile(remoteConfigPath())
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", remoteConfigPath(), err)
+	}
+	return &cfg, nil
+}
+
+// Writes the config file, readable by the user alone as it holds credentials
+func (cfg *remoteConfig) save() error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	path := remoteConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Returns the context, the current one if the name is empty
+func (cfg *remoteConfig) context(name string) (*remoteContext, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no context selected, run cascade login or pass --server")
+	}
+	for i := range cfg.Contexts {
+		if cfg.Contexts[i].Name == name {
+			return &cfg.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown context %q", name)
+}
+
+// Adds the context or replaces the one of the same name
+func (cfg *remoteConfig) set(ctx remoteContext) {
+	for i := range cfg.Contexts {
+		if cfg.Contexts[i].Name == ctx.Name {
+			cfg.Contexts[i] = ctx
+			return
+		}
+	}
+	cfg.Contexts = append(cfg.Contexts, ctx)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	log "github.com/wizenheimer/cascade/internal/logger"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"github.com/wizenheimer/cascade/service/runner"
+)
+
+// Log lines kept on the dashboard
+const dashboardLogs = 8
+
+var (
+	titleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	mutedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// Activity of a cluster gathered from the log entries of the session
+type clusterActivity struct {
+	Candidates int
+	Errors     int
+}
+
+// Live view of a session running in the foreground, quits once the session is done
+type dashboard struct {
+	ctx context.Context
+	// Set once the session is prepared, ahead of running the dashboard
+	run     *runner.Session
+	entries chan log.LogEntry
+
+	clusters map[string]*clusterActivity
+	logs     []log.LogEntry
+	now      time.Time
+	stopping bool
+}
+
+type entryMsg log.LogEntry
+type refreshMsg time.Time
+type doneMsg struct{}
+
+// Returns a dashboard for a session, the context stops the session once cancelled such as by a signal
+func newDashboard(ctx context.Context) *dashboard {
+	return &dashboard{
+		ctx:      ctx,
+		entries:  make(chan log.LogEntry, 100),
+		clusters: map[string]*clusterActivity{},
+		now:      time.Now(),
+	}
+}
+
+// Feeds the dashboard as a sink of the session.
+// Never holds up the session, entries are dropped once the dashboard falls behind
+func (d *dashboard) Write(entry log.LogEntry) error {
+	select {
+	case d.entries <- entry:
+	default:
+	}
+	return nil
+}
+
+func (d *dashboard) Init() tea.Cmd {
+	return tea.Batch(d.listen, refresh(), d.wait)
+}
+
+func (d *dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			d.stop()
+		}
+	case entryMsg:
+		d.record(log.LogEntry(msg))
+		return d, d.listen
+	case refreshMsg:
+		d.now = time.Time(msg)
+		if d.ctx.Err() != nil {
+			d.stop()
+		}
+		return d, refresh()
+	case doneMsg:
+		return d, tea.Quit
+	}
+	return d, nil
+}
+
+// Cancels the session, the dashboard stays up until its clusters wind down
+func (d *dashboard) stop() {
+	if d.stopping {
+		return
+	}
+	d.stopping = true
+	d.run.Cancel()
+}
+
+func (d *dashboard) listen() tea.Msg {
+	return entryMsg(<-d.entries)
+}
+
+func (d *dashboard) wait() tea.Msg {
+	<-d.run.Done()
+	return doneMsg{}
+}
+
+func refresh() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return refreshMsg(t)
+	})
+}
+
+func (d *dashboard) record(entry log.LogEntry) {
+	cluster, _ := entry.Fields["cluster"].(string)
+	activity, ok := d.clusters[cluster]
+	if !ok {
+		activity = &clusterActivity{}
+		d.clusters[cluster] = activity
+	}
+
+	if entry.Event == log.EventCandidatesSelected {
+		activity.Candidates = intField(entry.Fields["candidates"])
+	}
+	if entry.Level == "error" {
+		activity.Errors++
+	}
+
+	d.logs = append(d.logs, entry)
+	if len(d.logs) > dashboardLogs {
+		d.logs = d.logs[len(d.logs)-dashboardLogs:]
+	}
+}
+
+func (d *dashboard) View() string {
+	report := d.run.Report()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", titleStyle.Render("cascade"),
+		mutedStyle.Render(fmt.Sprintf("scenario %s · session %s ·", d.run.Config.Scenario, d.run.Config.ID)), styleStatus(report.Status))
+	b.WriteString("\n")
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSTATUS\tTICK\tCANDIDATES\tVICTIMS\tERRORS\tNEXT TICK")
+	for _, cluster := range report.Clusters {
+		activity := d.clusters[cluster.Cluster]
+		if activity == nil {
+			activity = &clusterActivity{}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n",
+			cluster.Cluster, cluster.Status, cluster.Rounds, activity.Candidates, cluster.Victims, activity.Errors, d.countdown(cluster))
+	}
+	w.Flush()
+	b.WriteString("\n")
+
+	for _, entry := range d.logs {
+		line := fmt.Sprintf("%s %-5s %s", time.UnixMilli(entry.Timestamp).Format(time.TimeOnly), entry.Level, entry.Message)
+		if entry.Level == "error" {
+			line = errorStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+
+	if d.stopping {
+		b.WriteString(mutedStyle.Render("Stopping, winding down ...") + "\n")
+	} else {
+		b.WriteString(mutedStyle.Render("q / ctrl+c to stop the session") + "\n")
+	}
+	return b.String()
+}
+
+// Returns the time left before the next tick of the cluster
+func (d *dashboard) countdown(cluster k8x.ClusterReport) string {
+	if cluster.Status != k8x.ClusterRunning || cluster.NextRound.IsZero() {
+		return "-"
+	}
+	left := cluster.NextRound.Sub(d.now).Round(time.Second)
+	if left <= 0 {
+		return "due"
+	}
+	return "in " + left.String()
+}
+
+func styleStatus(status string) string {
+	switch status {
+	case k8x.ClusterFailed:
+		return errorStyle.Render(status)
+	case k8x.ClusterCompleted:
+		return successStyle.Render(status)
+	default:
+		return status
+	}
+}
+
+// Numeric fields are decoded as int64 or float64 depending on the encoder
+func intField(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+// Prints the outcome of every cluster once the session is done
+func printReport(report *k8x.FanOutReport) {
+	fmt.Printf("Session %s\n\n", report.Status)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tSTATUS\tROUNDS\tVICTIMS\tFAILURES\tERROR")
+	for _, cluster := range report.Clusters {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			cluster.Cluster, cluster.Status, cluster.Rounds, cluster.Victims, cluster.Failures, orDash(cluster.Error))
+	}
+	w.Flush()
+}
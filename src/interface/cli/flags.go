@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wizenheimer/cascade/internal/config"
+	"golang.org/x/term"
+)
+
+// Exit codes of the CLI
+const (
+	exitFailed      = 1   // The scenario is invalid or the session failed
+	exitUsage       = 2   // Required values are missing or unreadable
+	exitInterrupted = 130 // The session was interrupted
+)
+
+// Flag setting a field of the scenario, fields whose flag is unset keep their value
+type configFlag struct {
+	name     string
+	category string
+	usage    string
+	field    func(cfg *config.Config) *string
+}
+
+var configFlags = []configFlag{
+	// Scenario
+	{"id", "Scenario", "Name of the chaos experiment", func(cfg *config.Config) *string { return &cfg.Scenario.ID }},
+	{"description", "Scenario", "Description of the chaos experiment", func(cfg *config.Config) *string { return &cfg.Scenario.Description }},
+
+	// Target
+	{"namespaces", "Target", "Namespace or set of namespaces to target, such as default or !kube-system", func(cfg *config.Config) *string { return &cfg.Target.Namespaces }},
+	{"included-pod-names", "Target", "Pods become targets if their name contains one of the comma separated strings", func(cfg *config.Config) *string { return &cfg.Target.IncludedPodNames }},
+	{"included-node-names", "Target", "Pods become targets if they reside on a node whose name contains one of the strings", func(cfg *config.Config) *string { return &cfg.Target.IncludedNodeNames }},
+	{"excluded-pod-names", "Target", "Pods are spared if their name contains one of the strings", func(cfg *config.Config) *string { return &cfg.Target.ExcludedPodNames }},
+
+	// Runtime
+	{"interval", "Runtime", "Interval at which the chaos experiments are triggered, such as 10m", func(cfg *config.Config) *string { return &cfg.Runtime.Interval }},
+	{"distribution", "Runtime", "How intervals are drawn, one of fixed, uniform or exponential", func(cfg *config.Config) *string { return &cfg.Runtime.Distribution }},
+	{"min-interval", "Runtime", "Shortest interval between chaos experiments", func(cfg *config.Config) *string { return &cfg.Runtime.MinInterval }},
+	{"max-interval", "Runtime", "Longest interval between chaos experiments", func(cfg *config.Config) *string { return &cfg.Runtime.MaxInterval }},
+	{"interval-jitter", "Runtime", "Spreads every interval by up to the percentage, such as 20%", func(cfg *config.Config) *string { return &cfg.Runtime.IntervalJitter }},
+	{"initial-delay", "Runtime", "Delay before the first chaos experiment, such as 1m", func(cfg *config.Config) *string { return &cfg.Runtime.InitialDelay }},
+	{"grace", "Runtime", "Grace time before the chaos experiment starts, such as 1m", func(cfg *config.Config) *string { return &cfg.Runtime.Grace }},
+	{"mode", "Runtime", "Mode of the chaos experiment, one of evict, delete or dry-run", func(cfg *config.Config) *string { return &cfg.Runtime.Mode }},
+	{"ordering", "Runtime", "Priority of the pods to target, one of oldest, youngest, cost, random or default", func(cfg *config.Config) *string { return &cfg.Runtime.Ordering }},
+	{"ratio", "Runtime", "Ratio of candidate pods to target", func(cfg *config.Config) *string { return &cfg.Runtime.Ratio }},
+	{"rounding", "Runtime", "How the ratio is rounded onto a number of victims, one of floor, ceil or round", func(cfg *config.Config) *string { return &cfg.Runtime.Rounding }},
+	{"count", "Runtime", "Absolute number of pods to target, takes precedence over the ratio", func(cfg *config.Config) *string { return &cfg.Runtime.Count }},
+	{"min-victims", "Runtime", "Least number of pods to target, as long as there are enough candidates", func(cfg *config.Config) *string { return &cfg.Runtime.MinVictims }},
+	{"max-victims", "Runtime", "Most number of pods to target", func(cfg *config.Config) *string { return &cfg.Runtime.MaxVictims }},
+	{"seed", "Runtime", "Seed victim selection, replaying the seed of a past session selects the same victims", func(cfg *config.Config) *string { return &cfg.Runtime.Seed }},
+	{"concurrency", "Runtime", "Number of pods acted upon at once", func(cfg *config.Config) *string { return &cfg.Runtime.Concurrency }},
+	{"rate-limit", "Runtime", "Actions started per second", func(cfg *config.Config) *string { return &cfg.Runtime.RateLimit }},
+	{"stagger", "Runtime", "Delay between actions on consecutive pods, such as 500ms", func(cfg *config.Config) *string { return &cfg.Runtime.Stagger }},
+	{"jitter", "Runtime", "Random delay added onto the stagger, such as 250ms", func(cfg *config.Config) *string { return &cfg.Runtime.Jitter }},
+
+	// Cluster
+	{"cluster", "Cluster", "ID of a cluster registered with the API server", func(cfg *config.Config) *string { return &cfg.Cluster.ID }},
+	{"kubeconfig", "Cluster", "Path to the kubeconfig file", func(cfg *config.Config) *string { return &cfg.Cluster.Kubeconfig }},
+	{"master", "Cluster", "Address of the Kubernetes API server, overriding the one of the kubeconfig", func(cfg *config.Config) *string { return &cfg.Cluster.Master }},
+	{"origin", "Cluster", "Where the chaos experiment runs from, one of host or cluster", func(cfg *config.Config) *string { return &cfg.Cluster.Origin }},
+	{"healthcheck", "Cluster", "Health check port for the pods", func(cfg *config.Config) *string { return &cfg.Cluster.Healthcheck }},
+
+	// Fan-out
+	{"fanout", "Fan-out", "How the scenario is spread across contexts, one of parallel or sequential", func(cfg *config.Config) *string { return &cfg.FanOut.Strategy }},
+	{"pause", "Fan-out", "Pause between contexts run one after the other, such as 1m", func(cfg *config.Config) *string { return &cfg.FanOut.Pause }},
+	{"rounds", "Fan-out", "Rounds run within every context, the session runs until interrupted if unset", func(cfg *config.Config) *string { return &cfg.FanOut.Rounds }},
+}
+
+// Returns a flag for every field of the scenario
+func scenarioFlags() []cli.Flag {
+	flags := make([]cli.Flag, 0, len(configFlags)+1)
+	for _, f := range configFlags {
+		flags = append(flags, &cli.StringFlag{Name: f.name, Category: f.category, Usage: f.usage})
+	}
+	return append(flags, &cli.StringSliceFlag{Name: "contexts", Category: "Cluster", Usage: "Kube contexts to target, may be repeated"})
+}
+
+// Sets the fields of the scenario whose flag is set
+func applyScenarioFlags(c *cli.Context, cfg *config.Config) {
+	for _, f := range configFlags {
+		if c.IsSet(f.name) {
+			*f.field(cfg) = c.String(f.name)
+		}
+	}
+	if c.IsSet("contexts") {
+		cfg.Cluster.Contexts = c.StringSlice("contexts")
+	}
+}
+
+// Forms are only shown on terminals, required values must be passed as flags otherwise
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -51,6 +51,27 @@ func createRuntimeGroup(config *config.Config) *huh.Group {
 			Title("Runtime Interval").
 			Description("Intervals at which the chaos experiments are to be triggered").
 			Value(&config.Runtime.Interval),
+		huh.NewSelect[string]().
+			Title("Runtime Distribution").
+			Description("How intervals between chaos experiments are drawn").
+			Options(huh.NewOptions("fixed", "uniform", "exponential")...).
+			Value(&config.Runtime.Distribution),
+		huh.NewInput().
+			Title("Runtime Min Interval").
+			Description("Shortest interval between chaos experiments, required by uniform intervals").
+			Value(&config.Runtime.MinInterval),
+		huh.NewInput().
+			Title("Runtime Max Interval").
+			Description("Longest interval between chaos experiments, required by uniform intervals").
+			Value(&config.Runtime.MaxInterval),
+		huh.NewInput().
+			Title("Runtime Interval Jitter").
+			Description("Spreads every interval by up to the percentage, such as 20%").
+			Value(&config.Runtime.IntervalJitter),
+		huh.NewInput().
+			Title("Runtime Initial Delay").
+			Description("Delay before the first chaos experiment, such as 1m").
+			Value(&config.Runtime.InitialDelay),
 		huh.NewInput().
 			Title("Runtime Grace").
 			Description("The grace time before the chaos experiment starts").
@@ -69,6 +90,39 @@ func createRuntimeGroup(config *config.Config) *huh.Group {
 			Title("Runtime Ratio").
 			Description("Ratio of candidate pods to be targeted").
 			Value(&config.Runtime.Ratio),
+		huh.NewSelect[string]().
+			Title("Runtime Rounding").
+			Description("How the ratio of candidate pods is rounded onto a number of victims").
+			Options(huh.NewOptions("floor", "ceil", "round")...).
+			Value(&config.Runtime.Rounding),
+		huh.NewInput().
+			Title("Runtime Count").
+			Description("Absolute number of pods to be targeted, takes precedence over the ratio").
+			Value(&config.Runtime.Count),
+		huh.NewInput().
+			Title("Runtime Min Victims").
+			Description("Least number of pods to be targeted, as long as there are enough candidates").
+			Value(&config.Runtime.MinVictims),
+		huh.NewInput().
+			Title("Runtime Max Victims").
+			Description("Most number of pods to be targeted, leave empty for no upper bound").
+			Value(&config.Runtime.MaxVictims),
+		huh.NewInput().
+			Title("Runtime Concurrency").
+			Description("Number of pods acted upon at once").
+			Value(&config.Runtime.Concurrency),
+		huh.NewInput().
+			Title("Runtime Rate Limit").
+			Description("Actions started per second, leave empty for no limit").
+			Value(&config.Runtime.RateLimit),
+		huh.NewInput().
+			Title("Runtime Stagger").
+			Description("Delay between actions on consecutive pods, such as 500ms").
+			Value(&config.Runtime.Stagger),
+		huh.NewInput().
+			Title("Runtime Jitter").
+			Description("Random delay added onto the stagger, such as 250ms").
+			Value(&config.Runtime.Jitter),
 	)
 
 	return runtimeGroup
@@ -2,18 +2,23 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"os"
-	"os/exec"
-	"time"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh/spinner"
+	"github.com/google/uuid"
 	"github.com/urfave/cli/v2"
 	"github.com/wizenheimer/cascade/internal/config"
 	"github.com/wizenheimer/cascade/internal/parser"
+	"github.com/wizenheimer/cascade/internal/tracing"
 	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"github.com/wizenheimer/cascade/service/runner"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v2"
+	"golang.org/x/term"
 )
 
 func main() {
@@ -23,10 +28,17 @@ func main() {
 		panic(err)
 	}
 
+	// Setup tracing
+	shutdown, err := tracing.Setup(context.Background(), "cascade-cli")
+	if err != nil {
+		logger.Fatal("failed to setup tracing", zap.Error(err))
+	}
+	defer shutdown(context.Background())
+
 	app := &cli.App{
 		Name:  "cascade",
 		Usage: "A CLI for managing chaos experiments",
-		Commands: []*cli.Command{
+		Commands: append([]*cli.Command{
 			{
 				Name:    "serve",
 				Aliases: []string{"s"},
@@ -34,43 +46,83 @@ func main() {
 				Subcommands: []*cli.Command{
 					{
 						Name:  "start",
-						Usage: "Start the server",
-						Action: func(c *cli.Context) error {
-							cmd := exec.Command("docker-compose", "up", "-d", "--build")
-							cmd.Stdout = os.Stdout
-							cmd.Stderr = os.Stderr
-							return cmd.Run()
-						},
+						Usage: "Start the server in the foreground, until interrupted",
+						Flags: append(databaseFlags(),
+							&cli.IntFlag{Name: "port", Usage: "Port the server listens on", Value: 8080, EnvVars: []string{"CASCADE_PORT"}},
+							&cli.StringFlag{Name: "log-level", Usage: "Least level logged, one of debug, info, warn or error", Value: "info", EnvVars: []string{"LOG_LEVEL"}},
+							&cli.BoolFlag{Name: "docker", Usage: "Build and start the server along with Postgres using docker-compose, from the root of the repository"},
+						),
+						Action: serve,
 					},
 					{
 						Name:  "stop",
-						Usage: "Stop the server",
+						Usage: "Stop the server started with --docker, removing its containers and volumes",
 						Action: func(c *cli.Context) error {
-							cmd := exec.Command("docker-compose", "down", "-v")
-							cmd.Stdout = os.Stdout
-							cmd.Stderr = os.Stderr
-							return cmd.Run()
+							return dockerCompose("down", "-v")
 						},
 					},
 				},
 			},
 			{
 				Name:  "create",
-				Usage: "Create a new chaos experiment scenario",
+				Usage: "Create a new chaos experiment scenario, prompting for the required values not passed as flags",
+				Flags: append(scenarioFlags(),
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Path the scenario is written to"},
+				),
+				Action: func(c *cli.Context) error {
+					return create(c, logger)
+				},
+			},
+			{
+				Name:      "validate",
+				Usage:     "Validate a chaos experiment scenario, reporting every invalid field",
+				ArgsUsage: "<file>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return cli.Exit("expected the path to a scenario", exitUsage)
+					}
+					return validate(c.Args().First())
+				},
+			},
+			{
+				Name:      "plan",
+				Usage:     "Preview the pods a chaos experiment would act upon, without acting",
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "seed", Usage: "Seed victim selection, overriding the seed of the scenario"},
+				},
 				Action: func(c *cli.Context) error {
-					return create(logger)
+					if c.NArg() != 1 {
+						return cli.Exit("expected the path to a scenario", exitUsage)
+					}
+					return plan(c.Context, c.Args().First(), c.String("seed"))
 				},
 			},
 			{
 				Name:  "exec",
-				Usage: "Trigger a chaos experiment session",
+				Usage: "Trigger a chaos experiment session, out of a scenario file or flags",
+				Flags: append(scenarioFlags(),
+					&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "Path to the scenario, flags override its fields"},
+				),
 				Action: func(c *cli.Context) error {
-					ctx, cancel := context.WithCancel(c.Context)
-					defer cancel()
-					return executor(logger, ctx)
+					// Registered clusters are only reachable through the server they're registered with
+					if c.IsSet("cluster") {
+						return cli.Exit("--cluster targets a cluster registered with a server, pass it to cascade session start instead", exitUsage)
+					}
+
+					config, err := loadScenario(c, logger)
+					if err != nil {
+						return err
+					}
+
+					// Stop the session upon an interrupt, its clusters wind down before exiting
+					ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+					defer stop()
+					return executor(logger, ctx, config)
 				},
 			},
-		},
+			migrateCommand(),
+		}, remoteCommands()...),
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -78,99 +130,192 @@ func main() {
 	}
 }
 
-func create(logger *zap.Logger) error {
+func create(c *cli.Context, logger *zap.Logger) error {
 	var cfg config.Config
+	applyScenarioFlags(c, &cfg)
 
 	var outputFolder string
 	var fileName string
 	var createFolder bool
+	if output := c.String("output"); output != "" {
+		outputFolder, fileName = filepath.Dir(output), filepath.Base(output)
+		createFolder = true
+	}
 
+	// Headless runs need every required value passed as a flag
+	if cfg.Scenario.ID != "" && fileName != "" {
+		if errs := parser.ValidateConfig(&cfg); len(errs) > 0 {
+			return reportErrors("scenario", errs)
+		}
+		return compose(cfg, fileName, outputFolder, createFolder)
+	}
+	if !interactive() {
+		return cli.Exit("missing required values, pass --id and --output", exitUsage)
+	}
+
+	// Values passed as flags prefill the form
 	form := createScenarioForm(&cfg, &outputFolder, &fileName, &createFolder)
 	if err := form.Run(); err != nil {
 		logger.Error(err.Error())
 		return err
 	}
+	if errs := parser.ValidateConfig(&cfg); len(errs) > 0 {
+		return reportErrors("scenario", errs)
+	}
 
-	if err := spinner.New().
+	var err error
+	if spinnerErr := spinner.New().
 		Title("Preparing YAML ...").
 		Action(func() {
-			compose(cfg, fileName, outputFolder, createFolder)
+			err = compose(cfg, fileName, outputFolder, createFolder)
 		}).
-		Run(); err != nil {
-		logger.Error(err.Error())
-		return err
+		Run(); spinnerErr != nil {
+		logger.Error(spinnerErr.Error())
+		return spinnerErr
+	}
+
+	return err
+}
+
+func validate(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cli.Exit(err.Error(), exitUsage)
 	}
 
+	if _, errs := parser.ValidateYAML(data); len(errs) > 0 {
+		return reportErrors(path, errs)
+	}
+
+	fmt.Printf("%s is valid\n", path)
 	return nil
 }
 
-func executor(logger *zap.Logger, ctx context.Context) error {
-	var inputPath string
+// Prints every invalid field along with its path, exits with a non-zero status
+func reportErrors(source string, errs parser.ValidationErrors) error {
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err.Error())
+	}
+	return cli.Exit(fmt.Sprintf("%s: found %d errors", source, len(errs)), exitFailed)
+}
 
-	form := createSessionForm(&inputPath)
-	if err := form.Run(); err != nil {
-		logger.Error(err.Error())
-		return err
+// Reads the scenario off its file, flags override the fields of the file.
+// The file is picked through a form unless either the file or a scenario ID are passed
+func loadScenario(c *cli.Context, logger *zap.Logger) (*config.Config, error) {
+	path := c.String("file")
+	if path == "" && !c.IsSet("id") {
+		if !interactive() {
+			return nil, cli.Exit("missing a scenario, pass --file or --id", exitUsage)
+		}
+		form := createSessionForm(&path)
+		if err := form.Run(); err != nil {
+			logger.Error(err.Error())
+			return nil, err
+		}
 	}
 
-	data, err := os.ReadFile(inputPath)
-	if err != nil {
-		log.Fatalf("Error reading YAML file: %v", err)
+	cfg := &config.Config{}
+	source := "scenario"
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, cli.Exit(err.Error(), exitUsage)
+		}
+		var errs parser.ValidationErrors
+		if cfg, errs = parser.DecodeYAML(data); len(errs) > 0 {
+			return nil, reportErrors(path, errs)
+		}
+		source = path
 	}
 
-	// Create a Scenario using YAML
-	var config config.Config
+	// Report every invalid field at once, including the ones passed as flags
+	applyScenarioFlags(c, cfg)
+	if errs := parser.ValidateConfig(cfg); len(errs) > 0 {
+		return nil, reportErrors(source, errs)
+	}
+	return cfg, nil
+}
 
-	err = yaml.Unmarshal(data, &config)
+func executor(logger *zap.Logger, ctx context.Context, config *config.Config) error {
+	tc, err := parser.ParseTargetConfig(config)
 	if err != nil {
 		return err
 	}
 
-	tc, err := parser.ParseTargetConfig(&config)
+	rc, err := parser.ParseRuntimeConfig(config)
 	if err != nil {
 		return err
 	}
 
-	rc, err := parser.ParseRuntimeConfig(&config)
+	ccs, err := parser.ParseClusterConfigs(config)
 	if err != nil {
 		return err
 	}
 
-	cc, err := parser.ParseClusterConfig(&config)
+	fc, err := parser.ParseFanOutConfig(config)
 	if err != nil {
 		return err
 	}
 
-	// Create executor
-	executor, err := k8x.CreateExecutor(cc, tc, rc, logger)
+	// Target every kube context
+	sessionID := uuid.NewString()
+	targets := make([]runner.Target, len(ccs))
+	for i, cc := range ccs {
+		name := cc.Context
+		if name == "" {
+			name = "default"
+		}
+		targets[i] = runner.Target{Name: name, Config: cc}
+	}
+
+	// Render a live dashboard onto terminals, in place of the logs
+	var sinks []runner.Sink
+	var dash *dashboard
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		dash = newDashboard(ctx)
+		sinks = append(sinks, dash)
+		logger = zap.NewNop()
+	}
+
+	run, err := runner.New(runner.Config{
+		ID:       sessionID,
+		Scenario: config.Scenario.ID,
+		Targets:  targets,
+		Target:   tc,
+		Runtime:  rc,
+		FanOut:   fc,
+	}, logger, runner.Hooks{}, sinks...)
 	if err != nil {
 		return err
 	}
 
-	// Create ticker
-	ticker := time.NewTicker(rc.Interval)
-	defer ticker.Stop()
-
-	// Start processing in a goroutine
-	go func(executor *k8x.Executor, sessionID string, scenarioID string, ctx context.Context, next <-chan time.Time) {
-		for {
-			executor.Logger.Info("Chaos Session Triggered", zap.Any("Session", sessionID))
-			executor.Logger.Info("Chaos Scenario", zap.Any("Scenario", scenarioID))
-
-			// Trigger Execution
-			if err = executor.Execute(ctx); err != nil {
-				executor.Logger.Error(err.Error())
-			}
-
-			select {
-			case <-next:
-				// trigger next session
-			case <-ctx.Done():
-				// skip subsequent execution
-				return
-			}
+	// Run in the foreground until every cluster is done or the session is interrupted
+	var report *k8x.FanOutReport
+	if dash == nil {
+		report = run.Run(ctx)
+	} else {
+		dash.run = run
+		run.Start(ctx)
+		if _, err := tea.NewProgram(dash, tea.WithoutSignalHandler()).Run(); err != nil {
+			run.Cancel()
+			run.Wait()
+			return err
 		}
-	}(executor, "nil", config.Scenario.ID, ctx, ticker.C)
+		report = run.Wait()
+	}
+	printReport(report)
 
+	interrupted := ctx.Err() != nil || dash != nil && dash.stopping
+	return sessionExit(report, interrupted)
+}
+
+// Maps the outcome of a session onto the exit code of the CLI
+func sessionExit(report *k8x.FanOutReport, interrupted bool) error {
+	switch {
+	case report.Status == k8x.ClusterFailed:
+		return cli.Exit(fmt.Sprintf("session failed, %d rounds failed", report.Failures), exitFailed)
+	case interrupted:
+		return cli.Exit("session interrupted", exitInterrupted)
+	}
 	return nil
 }
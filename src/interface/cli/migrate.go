@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wizenheimer/cascade/service/database"
+)
+
+// Applies, reverts and lists out the schema migrations embedded within the binary
+func migrateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "migrate",
+		Usage: "Migrate the schema of the database, the server applies pending migrations on startup unless AUTO_MIGRATE=false",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "up",
+				Usage: "Apply pending migrations in order",
+				Flags: append(databaseFlags(),
+					&cli.IntFlag{Name: "steps", Usage: "Number of migrations to apply, every pending one if unset"},
+				),
+				Action: func(c *cli.Context) error {
+					db, err := openDatabase(c)
+					if err != nil {
+						return err
+					}
+					applied, err := db.MigrateUp(c.Context, c.Int("steps"))
+					for _, m := range applied {
+						fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+					}
+					if err == nil && len(applied) == 0 {
+						fmt.Println("Schema is up to date")
+					}
+					return err
+				},
+			},
+			{
+				Name:  "down",
+				Usage: "Revert the latest applied migrations",
+				Flags: append(databaseFlags(),
+					&cli.IntFlag{Name: "steps", Usage: "Number of migrations to revert", Value: 1},
+					&cli.BoolFlag{Name: "all", Usage: "Revert every applied migration, dropping every table"},
+				),
+				Action: func(c *cli.Context) error {
+					steps := c.Int("steps")
+					if c.Bool("all") {
+						steps = 0
+					} else if steps < 1 {
+						return cli.Exit("--steps must be positive, pass --all to revert every migration", exitUsage)
+					}
+
+					db, err := openDatabase(c)
+					if err != nil {
+						return err
+					}
+					reverted, err := db.MigrateDown(c.Context, steps)
+					for _, m := range reverted {
+						fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+					}
+					if err == nil && len(reverted) == 0 {
+						fmt.Println("No migration to revert")
+					}
+					return err
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "List out the migrations, along with when they were applied",
+				Flags: databaseFlags(),
+				Action: func(c *cli.Context) error {
+					db, err := openDatabase(c)
+					if err != nil {
+						return err
+					}
+					states, err := db.MigrationStatus(c.Context)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+					for _, state := range states {
+						applied := "pending"
+						if state.AppliedAt != nil {
+							applied = state.AppliedAt.Local().Format(time.DateTime)
+						}
+						if !state.Known {
+							applied += " (unknown to this release)"
+						}
+						fmt.Fprintf(w, "%04d\t%s\t%s\n", state.Version, state.Name, applied)
+					}
+					return w.Flush()
+				},
+			},
+		},
+	}
+}
+
+// Connects to the database selected by the flags, which must be set
+func openDatabase(c *cli.Context) (database.DatabaseClient, error) {
+	dsn, err := databaseDSN(c)
+	if err != nil {
+		return nil, err
+	}
+	if dsn == "" {
+		return nil, cli.Exit("missing database, pass --db or --embedded, or set DATABASE_URL", exitUsage)
+	}
+	return database.NewDatabaseClientFromDSN(dsn)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/wizenheimer/cascade/internal/parser"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"go.uber.org/zap"
+)
+
+// Previews the pods a session would act upon within every cluster the scenario targets
+func plan(ctx context.Context, path string, seed string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cli.Exit(err.Error(), exitUsage)
+	}
+
+	config, errs := parser.ValidateYAML(data)
+	if len(errs) > 0 {
+		return errs
+	}
+	if seed != "" {
+		config.Runtime.Seed = seed
+	}
+
+	tc, err := parser.ParseTargetConfig(config)
+	if err != nil {
+		return err
+	}
+
+	rc, err := parser.ParseRuntimeConfig(config)
+	if err != nil {
+		return err
+	}
+
+	ccs, err := parser.ParseClusterConfigs(config)
+	if err != nil {
+		return err
+	}
+
+	for _, cc := range ccs {
+		name := cc.Context
+		if name == "" {
+			name = "default"
+		}
+
+		// Selection logs would clutter the table
+		executor, err := k8x.CreateExecutor(cc, tc, rc, zap.NewNop())
+		if err != nil {
+			return err
+		}
+
+		p, err := executor.Plan(ctx)
+		executor.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		printPlan(name, p)
+	}
+
+	return nil
+}
+
+func printPlan(cluster string, p *k8x.Plan) {
+	fmt.Printf("Cluster %s: %d candidates, %d victims (mode %s, ordering %s, ratio %g, seed %d)\n\n",
+		cluster, p.Candidates, p.Victims, p.Mode, p.Ordering, p.Ratio, p.Seed)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ORDER\tNAMESPACE\tPOD\tWORKLOAD\tNODE\tAGE\tSAMPLED\tSKIPPED BY")
+	for _, pod := range p.Pods {
+		order := "-"
+		if pod.Order > 0 {
+			order = fmt.Sprint(pod.Order)
+		}
+		age := "-"
+		if pod.StartTime != nil {
+			age = time.Since(*pod.StartTime).Round(time.Second).String()
+		}
+		sampled := "no"
+		if pod.Sampled {
+			sampled = "yes"
+		}
+		skipped := "-"
+		if len(pod.Skipped) > 0 {
+			skipped = strings.Join(pod.Skipped, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			order, pod.Namespace, pod.Name, orDash(pod.Workload), orDash(pod.Node), age, sampled, skipped)
+	}
+	w.Flush()
+	fmt.Println()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
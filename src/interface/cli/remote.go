@@ -0,0 +1,675 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+	"github.com/wizenheimer/cascade/internal/audit"
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/parser"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"gopkg.in/yaml.v2"
+)
+
+// Commands talking to a running Cascade server
+func remoteCommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "login",
+			Usage: "Log onto a server, storing its credentials as a context of the config file",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "server", Usage: "Address of the server, such as http://localhost:8080", EnvVars: []string{"CASCADE_SERVER"}},
+				&cli.StringFlag{Name: "context", Usage: "Name of the context, defaults to the host of the server"},
+				&cli.StringFlag{Name: "email", Usage: "Email of the user"},
+				&cli.StringFlag{Name: "password", Usage: "Password of the user", EnvVars: []string{"CASCADE_PASSWORD"}},
+				&cli.StringFlag{Name: "api-key", Usage: "API Key used in place of an email and password", EnvVars: []string{"CASCADE_API_KEY"}},
+				&cli.StringFlag{Name: "team", Usage: "Team scenarios are pushed onto and listed from"},
+			},
+			Action: login,
+		},
+		{
+			Name:  "context",
+			Usage: "Manage the servers of the config file",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "list",
+					Usage:  "List out the contexts, marking the current one",
+					Action: listContexts,
+				},
+				{
+					Name:      "use",
+					Usage:     "Select the context remote commands use by default",
+					ArgsUsage: "<name>",
+					Action:    useContext,
+				},
+			},
+		},
+		{
+			Name:  "scenario",
+			Usage: "Manage the scenarios stored on a server",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "push",
+					Usage:     "Store a scenario, as a new version if it already exists",
+					ArgsUsage: "<file>",
+					Flags: remoteFlags(
+						&cli.StringFlag{Name: "id", Usage: "ID of the scenario to update, defaults to the ID within the file"},
+						&cli.StringFlag{Name: "team", Usage: "Team owning the scenario, defaults to the team of the context"},
+					),
+					Action: pushScenario,
+				},
+				{
+					Name:   "list",
+					Usage:  "List out the scenarios of a team",
+					Flags:  remoteFlags(&cli.StringFlag{Name: "team", Usage: "Team owning the scenarios, defaults to the team of the context"}),
+					Action: listScenarios,
+				},
+				{
+					Name:      "show",
+					Usage:     "Print a scenario version as YAML",
+					ArgsUsage: "<id>",
+					Flags:     remoteFlags(&cli.IntFlag{Name: "version", Usage: "Version of the scenario, defaults to the latest"}),
+					Action:    showScenario,
+				},
+				{
+					Name:      "diff",
+					Usage:     "Compare a scenario file against a stored version, exits with 1 if they differ",
+					ArgsUsage: "<file>",
+					Flags: remoteFlags(
+						&cli.StringFlag{Name: "id", Usage: "ID of the stored scenario, defaults to the ID within the file"},
+						&cli.IntFlag{Name: "version", Usage: "Version of the scenario, defaults to the latest"},
+					),
+					Action: diffScenario,
+				},
+			},
+		},
+		{
+			Name:  "session",
+			Usage: "Manage the sessions running on a server",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "start",
+					Usage:     "Start a session and follow its logs, interrupting it stops the session unless detached",
+					ArgsUsage: "<scenario>",
+					Flags: remoteFlags(
+						&cli.IntFlag{Name: "version", Usage: "Version of the scenario, defaults to the latest"},
+						&cli.StringSliceFlag{Name: "cluster", Usage: "Registered cluster to target, may be repeated"},
+						&cli.StringSliceFlag{Name: "kube-context", Usage: "Kube context of the server's kubeconfig to target, admins only, may be repeated"},
+						&cli.StringFlag{Name: "fanout", Usage: "How the session is spread across clusters, one of parallel or sequential"},
+						&cli.StringFlag{Name: "pause", Usage: "Pause between clusters run one after the other"},
+						&cli.StringFlag{Name: "rounds", Usage: "Rounds run within every cluster, the session runs until stopped if unset"},
+						&cli.StringFlag{Name: "seed", Usage: "Seed victim selection"},
+						&cli.StringFlag{Name: "replay", Usage: "Replay the seed of a past session"},
+						&cli.BoolFlag{Name: "detach", Usage: "Print the ID of the session and return, leaving it running"},
+					),
+					Action: startSession,
+				},
+				{
+					Name:      "attach",
+					Usage:     "Follow the logs of a running session, interrupting leaves the session running",
+					ArgsUsage: "<session>",
+					Flags:     remoteFlags(),
+					Action:    attachSession,
+				},
+				{
+					Name:      "stop",
+					Usage:     "Stop a running session",
+					ArgsUsage: "<session>",
+					Flags:     remoteFlags(),
+					Action:    stopSession,
+				},
+				{
+					Name:      "list",
+					Usage:     "List out the sessions of a scenario",
+					ArgsUsage: "<scenario>",
+					Flags:     remoteFlags(&cli.IntFlag{Name: "version", Usage: "Only list sessions of this version"}),
+					Action:    listSessions,
+				},
+			},
+		},
+		{
+			Name:      "report",
+			Usage:     "Print the combined report of a session across its clusters",
+			ArgsUsage: "<session>",
+			Flags:     remoteFlags(),
+			Action:    reportSession,
+		},
+	}
+}
+
+// Returns the single argument of the command
+func argument(c *cli.Context, name string) (string, error) {
+	if c.NArg() != 1 {
+		return "", cli.Exit(fmt.Sprintf("expected the %s", name), exitUsage)
+	}
+	return c.Args().First(), nil
+}
+
+// Maps errors of remote commands onto the exit code of the CLI
+func remoteExit(err error) error {
+	var rerr *remoteError
+	if errors.As(err, &rerr) {
+		return cli.Exit(rerr.Error(), exitFailed)
+	}
+	return err
+}
+
+func login(c *cli.Context) error {
+	server, email, password, key := c.String("server"), c.String("email"), c.String("password"), c.String("api-key")
+
+	// Prompt for whatever is missing, on terminals alone
+	if server == "" || (key == "" && (email == "" || password == "")) {
+		if !interactive() {
+			return cli.Exit("missing required values, pass --server along with --api-key or --email and --password", exitUsage)
+		}
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewInput().Title("Server").Description("Address of the server, such as http://localhost:8080").Value(&server),
+			huh.NewInput().Title("Email").Value(&email),
+			huh.NewInput().Title("Password").EchoMode(huh.EchoModePassword).Value(&password),
+		))
+		if err := form.Run(); err != nil {
+			return err
+		}
+	}
+
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return cli.Exit(fmt.Sprintf("invalid server %q, expected an http(s) url", server), exitUsage)
+	}
+	name := c.String("context")
+	if name == "" {
+		name = u.Host
+	}
+
+	client := &remoteClient{server: server, token: key, http: &http.Client{}}
+	if key == "" {
+		var token struct {
+			Token string `json:"token"`
+		}
+		err := client.send(c.Context, http.MethodPost, "/auth/"+url.PathEscape(email), url.Values{"password": {password}}, &token)
+		if err != nil {
+			return remoteExit(err)
+		}
+		client.token = token.Token
+	} else if err := client.get(c.Context, "/auth/keys", nil, nil); err != nil {
+		// Check the API Key ahead of storing it
+		return remoteExit(err)
+	}
+
+	cfg, err := loadRemoteConfig()
+	if err != nil {
+		return err
+	}
+	cfg.set(remoteContext{Name: name, Server: server, Token: client.token, Team: c.String("team")})
+	cfg.CurrentContext = name
+	if err := cfg.save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged onto %s, saved as context %s\n", server, name)
+	return nil
+}
+
+func listContexts(c *cli.Context) error {
+	cfg, err := loadRemoteConfig()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tTEAM")
+	for _, rc := range cfg.Contexts {
+		current := ""
+		if rc.Name == cfg.CurrentContext {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, rc.Name, rc.Server, orDash(rc.Team))
+	}
+	return w.Flush()
+}
+
+func useContext(c *cli.Context) error {
+	name, err := argument(c, "name of the context")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadRemoteConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.context(name); err != nil {
+		return cli.Exit(err.Error(), exitUsage)
+	}
+	cfg.CurrentContext = name
+	if err := cfg.save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Switched to context %s\n", name)
+	return nil
+}
+
+func pushScenario(c *cli.Context) error {
+	path, err := argument(c, "path to a scenario")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cli.Exit(err.Error(), exitUsage)
+	}
+	// Report invalid fields without a round trip
+	cfg, errs := parser.ValidateYAML(data)
+	if len(errs) > 0 {
+		return reportErrors(path, errs)
+	}
+
+	id := c.String("id")
+	if id == "" {
+		id = cfg.Scenario.ID
+	}
+	team := c.String("team")
+	if team == "" {
+		team = client.team
+	}
+
+	// Scenarios which exist get a new version, the others are created.
+	// The server assigns UUIDs, so IDs which aren't one can't exist on it yet
+	exists := false
+	if _, err := uuid.Parse(id); err == nil {
+		err := client.get(c.Context, "/scenario/"+url.PathEscape(id), nil, nil)
+		var rerr *remoteError
+		switch {
+		case err == nil:
+			exists = true
+		case !errors.As(err, &rerr) || rerr.Status != http.StatusNotFound:
+			return remoteExit(err)
+		}
+	}
+
+	method, endpoint := http.MethodPost, "/scenario"
+	if exists {
+		method, endpoint = http.MethodPatch, "/scenario/"+url.PathEscape(id)
+	}
+	var scenario models.Scenario
+	if err := client.sendFile(c.Context, method, endpoint, "config", path, data, url.Values{"team": {team}}, &scenario); err != nil {
+		return remoteExit(err)
+	}
+
+	fmt.Printf("Pushed scenario %s, version %d\n", scenario.ID, scenario.Version)
+	// The server assigns the ID of new scenarios, later versions must be pushed under it
+	if !exists && scenario.ID != id {
+		fmt.Fprintf(os.Stderr, "Set the ID within %s to %s, or pass --id %s, to push later versions\n", path, scenario.ID, scenario.ID)
+	}
+	return nil
+}
+
+func listScenarios(c *cli.Context) error {
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+	team := c.String("team")
+	if team == "" {
+		team = client.team
+	}
+
+	var scenarios []models.Scenario
+	if err := client.get(c.Context, "/scenario", url.Values{"team": {team}}, &scenarios); err != nil {
+		return remoteExit(err)
+	}
+
+	// Every version is listed, keep the latest one of every scenario
+	latest := map[string]models.Scenario{}
+	var ids []string
+	for _, scenario := range scenarios {
+		known, ok := latest[scenario.ID]
+		if !ok {
+			ids = append(ids, scenario.ID)
+		}
+		if !ok || scenario.Version > known.Version {
+			latest[scenario.ID] = scenario
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tVERSION\tCREATED\tDESCRIPTION")
+	for _, id := range ids {
+		scenario := latest[id]
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", scenario.ID, scenario.Version, scenario.CreatedAt.Format(time.DateTime), orDash(scenario.Description))
+	}
+	return w.Flush()
+}
+
+// Fetches the version of the scenario, the latest one if zero
+func fetchScenario(ctx context.Context, client *remoteClient, id string, version int) (models.Scenario, error) {
+	var scenario models.Scenario
+	if version == 0 {
+		var versions []models.Scenario
+		if err := client.get(ctx, "/scenario/"+url.PathEscape(id), nil, &versions); err != nil {
+			return scenario, err
+		}
+		// Versions are listed latest first
+		if len(versions) == 0 {
+			return scenario, &remoteError{Status: http.StatusNotFound}
+		}
+		version = versions[0].Version
+	}
+
+	err := client.get(ctx, fmt.Sprintf("/scenario/%s/%d", url.PathEscape(id), version), nil, &scenario)
+	return scenario, err
+}
+
+func showScenario(c *cli.Context) error {
+	id, err := argument(c, "ID of a scenario")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	scenario, err := fetchScenario(c.Context, client, id, c.Int("version"))
+	if err != nil {
+		return remoteExit(err)
+	}
+
+	data, err := yaml.Marshal(parser.ParseScenarioToConfig(scenario))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("# Version %d, created %s\n%s", scenario.Version, scenario.CreatedAt.Format(time.DateTime), data)
+	return nil
+}
+
+func diffScenario(c *cli.Context) error {
+	path, err := argument(c, "path to a scenario")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cli.Exit(err.Error(), exitUsage)
+	}
+	cfg, errs := parser.ValidateYAML(data)
+	if len(errs) > 0 {
+		return reportErrors(path, errs)
+	}
+	local, err := parser.ParseYAMLConfigToScenario(cfg)
+	if err != nil {
+		return err
+	}
+
+	id := c.String("id")
+	if id == "" {
+		id = cfg.Scenario.ID
+	}
+	stored, err := fetchScenario(c.Context, client, id, c.Int("version"))
+	if err != nil {
+		return remoteExit(err)
+	}
+
+	// Only compare the fields the file defines
+	local.ID, local.Version, local.TeamID, local.CreatedAt = stored.ID, stored.Version, stored.TeamID, stored.CreatedAt
+	diff, err := audit.Diff(stored, *local)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Printf("%s matches scenario %s, version %d\n", path, stored.ID, stored.Version)
+		return nil
+	}
+
+	var changes map[string]audit.Change
+	if err := json.Unmarshal([]byte(diff), &changes); err != nil {
+		return err
+	}
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	fmt.Printf("%s differs from scenario %s, version %d\n\n", path, stored.ID, stored.Version)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tSTORED\tFILE")
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s\t%v\t%v\n", field, changes[field].From, changes[field].To)
+	}
+	w.Flush()
+	return cli.Exit("", exitFailed)
+}
+
+func startSession(c *cli.Context) error {
+	scenarioID, err := argument(c, "ID of a scenario")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	version := c.Int("version")
+	if version == 0 {
+		scenario, err := fetchScenario(c.Context, client, scenarioID, 0)
+		if err != nil {
+			return remoteExit(err)
+		}
+		version = scenario.Version
+	}
+
+	form := url.Values{
+		"cluster": c.StringSlice("cluster"),
+		"context": c.StringSlice("kube-context"),
+	}
+	for _, name := range []string{"fanout", "pause", "rounds", "seed", "replay"} {
+		if c.IsSet(name) {
+			form.Set(name, c.String(name))
+		}
+	}
+	endpoint := fmt.Sprintf("/session/%s/%d", url.PathEscape(scenarioID), version)
+
+	if c.Bool("detach") {
+		form.Set("detach", "true")
+		var session models.Session
+		if err := client.send(c.Context, http.MethodPost, endpoint, form, &session); err != nil {
+			return remoteExit(err)
+		}
+		fmt.Fprintf(os.Stderr, "Started session %d, follow it using cascade session attach %d\n", session.ID, session.ID)
+		fmt.Println(session.ID)
+		return nil
+	}
+
+	// The server stops the session once the stream is closed
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stream, err := client.stream(ctx, http.MethodPost, endpoint, form)
+	if err != nil {
+		return remoteExit(err)
+	}
+	if err := followSession(ctx, client, stream, ""); ctx.Err() == nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Interrupted, the server stops the session")
+	return cli.Exit("", exitInterrupted)
+}
+
+func attachSession(c *cli.Context) error {
+	sessionID, err := argument(c, "ID of a session")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	// Detaching leaves the session running
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stream, err := client.stream(ctx, http.MethodGet, "/session/"+url.PathEscape(sessionID)+"/logs", nil)
+	if err != nil {
+		return remoteExit(err)
+	}
+	if err := followSession(ctx, client, stream, sessionID); ctx.Err() == nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Detached, session %s keeps running\n", sessionID)
+	return nil
+}
+
+// Renders the log stream of a session until it ends, then prints its report.
+// The session is identified by the stream unless passed
+func followSession(ctx context.Context, client *remoteClient, stream io.ReadCloser, sessionID string) error {
+	defer stream.Close()
+
+	err := readEvents(stream, func(entry log.LogEntry) {
+		if sessionID == "" {
+			sessionID = entry.Session
+		}
+		printLogEntry(entry)
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	if sessionID == "" {
+		return nil
+	}
+
+	var report struct {
+		Session models.Session `json:"session"`
+		*k8x.FanOutReport
+	}
+	if err := client.get(ctx, "/session/"+url.PathEscape(sessionID)+"/report", nil, &report); err != nil {
+		return remoteExit(err)
+	}
+	fmt.Println()
+	printReport(report.FanOutReport)
+	return sessionExit(report.FanOutReport, false)
+}
+
+// Prints a log entry of the stream onto the terminal, along with its fields
+func printLogEntry(entry log.LogEntry) {
+	keys := make([]string, 0, len(entry.Fields))
+	for key := range entry.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	line := fmt.Sprintf("%s %-5s %s", time.UnixMilli(entry.Timestamp).Format(time.TimeOnly), entry.Level, entry.Message)
+	switch entry.Level {
+	case "error", "fatal":
+		line = errorStyle.Render(line)
+	case "warn":
+		line = warnStyle.Render(line)
+	}
+	for _, key := range keys {
+		line += " " + mutedStyle.Render(fmt.Sprintf("%s=%v", key, entry.Fields[key]))
+	}
+	fmt.Println(line)
+}
+
+func stopSession(c *cli.Context) error {
+	sessionID, err := argument(c, "ID of a session")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	if err := client.send(c.Context, http.MethodPost, "/session/"+url.PathEscape(sessionID)+"/stop", nil, nil); err != nil {
+		return remoteExit(err)
+	}
+	fmt.Printf("Stopping session %s, its clusters wind down their current round\n", sessionID)
+	return nil
+}
+
+func listSessions(c *cli.Context) error {
+	scenarioID, err := argument(c, "ID of a scenario")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"scenario": {scenarioID}}
+	if version := c.Int("version"); version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+	var sessions []models.Session
+	if err := client.get(c.Context, "/session", query, &sessions); err != nil {
+		return remoteExit(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tVERSION\tSTATUS\tSTARTED\tENDED\tSEED\tOWNER")
+	for _, session := range sessions {
+		ended := "-"
+		if !session.EndTime.IsZero() {
+			ended = session.EndTime.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\t%d\t%s\n",
+			session.ID, session.Version, session.Status, session.StartTime.Format(time.DateTime), ended, session.Seed, orDash(session.Owner))
+	}
+	return w.Flush()
+}
+
+func reportSession(c *cli.Context) error {
+	sessionID, err := argument(c, "ID of a session")
+	if err != nil {
+		return err
+	}
+	client, err := newRemoteClient(c)
+	if err != nil {
+		return err
+	}
+
+	var report struct {
+		Session models.Session `json:"session"`
+		*k8x.FanOutReport
+	}
+	if err := client.get(c.Context, "/session/"+url.PathEscape(sessionID)+"/report", nil, &report); err != nil {
+		return remoteExit(err)
+	}
+
+	session := report.Session
+	fmt.Printf("Session %d of scenario %s, version %d, seed %d\n", session.ID, session.ScenarioID, session.Version, session.Seed)
+	printReport(report.FanOutReport)
+	return nil
+}
@@ -0,0 +1,452 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/parser"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"github.com/wizenheimer/cascade/service/runner"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Resync period of the informers
+const resync = 10 * time.Minute
+
+// Item queued for reconciliation
+type request struct {
+	Resource  schema.GroupVersionResource
+	Namespace string
+	Name      string
+}
+
+func (req request) String() string {
+	return fmt.Sprintf("%s %s/%s", req.Resource.Resource, req.Namespace, req.Name)
+}
+
+// Reconciles ChaosScenario and ChaosSession custom resources
+type Controller struct {
+	// Client for the custom resources
+	Dynamic dynamic.Interface
+	// Cluster the sessions act upon
+	Cluster *k8x.ClusterConfig
+	// Client side logger
+	Logger *zap.Logger
+
+	informers dynamicinformer.DynamicSharedInformerFactory
+	queue     workqueue.RateLimitingInterface
+	// Closed once the controller shuts down
+	done chan struct{}
+
+	// Running sessions, keyed by namespace/name
+	mu       sync.Mutex
+	sessions map[string]context.CancelFunc
+}
+
+// Initializes a controller instance, watching a single namespace if set
+func NewController(cc *k8x.ClusterConfig, namespace string, logger *zap.Logger) (*Controller, error) {
+	config, err := k8x.RestConfig(cc)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	controller := &Controller{
+		Dynamic:   client,
+		Cluster:   cc,
+		Logger:    logger,
+		informers: dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, resync, namespace, nil),
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		sessions:  make(map[string]context.CancelFunc),
+		done:      make(chan struct{}),
+	}
+
+	for _, resource := range []schema.GroupVersionResource{ScenarioResource, SessionResource} {
+		resource := resource
+		enqueue := func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+			controller.queue.Add(request{Resource: resource, Namespace: namespace, Name: name})
+		}
+
+		_, err := controller.informers.ForResource(resource).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueue,
+			UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+			DeleteFunc: enqueue,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return controller, nil
+}
+
+// Run the controller until the context is cancelled
+func (controller *Controller) Run(ctx context.Context, workers int) error {
+	defer controller.queue.ShutDown()
+
+	controller.informers.Start(ctx.Done())
+	for resource, synced := range controller.informers.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to sync the %s informer", resource.Resource)
+		}
+	}
+
+	controller.Logger.Info("Controller started", zap.Int("workers", workers))
+	for i := 0; i < workers; i++ {
+		go func() {
+			for controller.processNextItem(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	close(controller.done)
+
+	// Stop every running session
+	controller.mu.Lock()
+	for _, cancel := range controller.sessions {
+		cancel()
+	}
+	controller.mu.Unlock()
+
+	return nil
+}
+
+func (controller *Controller) processNextItem(ctx context.Context) bool {
+	item, shutdown := controller.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer controller.queue.Done(item)
+
+	req := item.(request)
+
+	var err error
+	switch req.Resource {
+	case ScenarioResource:
+		err = controller.reconcileScenario(ctx, req)
+	case SessionResource:
+		err = controller.reconcileSession(ctx, req)
+	}
+
+	if err != nil {
+		controller.Logger.Error("failed to reconcile", zap.Stringer("request", req), zap.Error(err))
+		controller.queue.AddRateLimited(item)
+		return true
+	}
+
+	controller.queue.Forget(item)
+	return true
+}
+
+// Validates the scenario, and records the outcome as its Valid condition
+func (controller *Controller) reconcileScenario(ctx context.Context, req request) error {
+	var scenario ChaosScenario
+	found, err := controller.get(req, &scenario)
+	if err != nil || !found {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Parsed",
+		Message:            "Scenario parsed successfully",
+		ObservedGeneration: scenario.Generation,
+	}
+	if _, _, err := parseScenario(&scenario); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		condition.Message = err.Error()
+	}
+
+	if scenario.Status.ObservedGeneration == scenario.Generation && !meta.SetStatusCondition(&scenario.Status.Conditions, condition) {
+		return nil
+	}
+
+	return controller.updateStatus(ctx, req, func(obj *unstructured.Unstructured) error {
+		var latest ChaosScenario
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &latest); err != nil {
+			return err
+		}
+		latest.Status.ObservedGeneration = latest.Generation
+		meta.SetStatusCondition(&latest.Status.Conditions, condition)
+		return setStatus(obj, latest.Status)
+	})
+}
+
+// Starts pending sessions, stops the ones which got deleted
+func (controller *Controller) reconcileSession(ctx context.Context, req request) error {
+	key := req.Namespace + "/" + req.Name
+
+	var session ChaosSession
+	found, err := controller.get(req, &session)
+	if err != nil {
+		return err
+	}
+	if !found {
+		controller.stopSession(key)
+		return nil
+	}
+
+	// Sessions run at most once, stale events queued before the session got marked as running leave it be
+	controller.mu.Lock()
+	_, running := controller.sessions[key]
+	controller.mu.Unlock()
+	if running {
+		return nil
+	}
+
+	switch session.Status.Phase {
+	case PhaseCompleted, PhaseFailed:
+		return nil
+	case PhaseRunning:
+		// The controller restarted while the session was running
+		return controller.finishSession(ctx, req, PhaseFailed, "ControllerRestarted", "Session was interrupted by a controller restart", nil)
+	}
+
+	// Resolve the scenario the session runs
+	var scenario ChaosScenario
+	found, err = controller.get(request{Resource: ScenarioResource, Namespace: req.Namespace, Name: session.Spec.ScenarioRef}, &scenario)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return controller.finishSession(ctx, req, PhaseFailed, "ScenarioNotFound", fmt.Sprintf("ChaosScenario %q not found", session.Spec.ScenarioRef), nil)
+	}
+
+	tc, rc, err := parseScenario(&scenario)
+	if err != nil {
+		return controller.finishSession(ctx, req, PhaseFailed, "InvalidScenario", err.Error(), nil)
+	}
+
+	if session.Spec.Seed != nil {
+		rc.Seed = *session.Spec.Seed
+	}
+
+	hooks := runner.Hooks{
+		OnUpdate: func(ctx context.Context, run *runner.Session, report k8x.ClusterReport) {
+			err := controller.updateSessionStatus(ctx, req, func(status *ChaosSessionStatus) {
+				status.Rounds = report.Rounds
+				status.Victims = report.Victims
+				status.Failures = report.Failures
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				run.Logger.Error("failed to record session progress", zap.Error(err))
+			}
+		},
+		OnEnd: func(ctx context.Context, run *runner.Session, report *k8x.FanOutReport) {
+			// Sessions interrupted by the controller shutting down are failed once it's back
+			if controller.stopping() {
+				return
+			}
+
+			phase, reason, message := PhaseCompleted, "Completed", fmt.Sprintf("Ran %d rounds, acted upon %d victims", report.Clusters[0].Rounds, report.Victims)
+			if report.Status == k8x.ClusterFailed {
+				phase, reason, message = PhaseFailed, "ActionFailed", report.Clusters[0].Error
+			}
+
+			err := controller.finishSession(ctx, req, phase, reason, message, &report.Clusters[0])
+			if err != nil && !apierrors.IsNotFound(err) {
+				run.Logger.Error("failed to record session completion", zap.Error(err))
+			}
+		},
+	}
+
+	run, err := runner.New(runner.Config{
+		ID:       key,
+		Scenario: scenario.Namespace + "/" + scenario.Name,
+		Targets:  []runner.Target{{Name: "in-cluster", Config: controller.Cluster}},
+		Target:   tc,
+		Runtime:  rc,
+		FanOut:   &k8x.FanOutConfig{Rounds: session.Spec.Rounds},
+	}, controller.Logger, hooks)
+	if err != nil {
+		return controller.finishSession(ctx, req, PhaseFailed, "InvalidSession", err.Error(), nil)
+	}
+
+	// Track the session before marking it as running, so the resulting update isn't mistaken for a restart
+	controller.mu.Lock()
+	controller.sessions[key] = run.Cancel
+	controller.mu.Unlock()
+
+	// Mark the session as running before acting upon the cluster
+	now := metav1.Now()
+	err = controller.updateSessionStatus(ctx, req, func(status *ChaosSessionStatus) {
+		status.Phase = PhaseRunning
+		status.StartTime = &now
+		status.Seed = rc.Seed
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    ConditionRunning,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Started",
+			Message: fmt.Sprintf("Running ChaosScenario %q", scenario.Name),
+		})
+	})
+	if err != nil {
+		controller.stopSession(key)
+		run.Release()
+		return err
+	}
+
+	go func() {
+		defer controller.stopSession(key)
+		run.Run(ctx)
+	}()
+
+	return nil
+}
+
+// Marks the session as done
+func (controller *Controller) finishSession(ctx context.Context, req request, phase, reason, message string, report *k8x.ClusterReport) error {
+	now := metav1.Now()
+	return controller.updateSessionStatus(ctx, req, func(status *ChaosSessionStatus) {
+		status.Phase = phase
+		status.CompletionTime = &now
+		if report != nil {
+			status.Rounds = report.Rounds
+			status.Victims = report.Victims
+			status.Failures = report.Failures
+		}
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:   ConditionRunning,
+			Status: metav1.ConditionFalse,
+			Reason: reason,
+		})
+
+		completed := metav1.ConditionTrue
+		if phase == PhaseFailed {
+			completed = metav1.ConditionFalse
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    ConditionCompleted,
+			Status:  completed,
+			Reason:  reason,
+			Message: message,
+		})
+	})
+}
+
+// Reports whether the session was cancelled by the controller shutting down, rather than by its deletion
+func (controller *Controller) stopping() bool {
+	select {
+	case <-controller.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Cancels a running session, if any
+func (controller *Controller) stopSession(key string) {
+	controller.mu.Lock()
+	defer controller.mu.Unlock()
+
+	if cancel, ok := controller.sessions[key]; ok {
+		cancel()
+		delete(controller.sessions, key)
+	}
+}
+
+// Fetches the custom resource from the informer cache, reports whether it exists
+func (controller *Controller) get(req request, into interface{}) (bool, error) {
+	obj, err := controller.informers.ForResource(req.Resource).Lister().ByNamespace(req.Namespace).Get(req.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected object %T", obj)
+	}
+	return true, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, into)
+}
+
+// Updates the status of the session, retrying on conflicts
+func (controller *Controller) updateSessionStatus(ctx context.Context, req request, mutate func(*ChaosSessionStatus)) error {
+	return controller.updateStatus(ctx, req, func(obj *unstructured.Unstructured) error {
+		var session ChaosSession
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &session); err != nil {
+			return err
+		}
+		session.Status.ObservedGeneration = session.Generation
+		mutate(&session.Status)
+		return setStatus(obj, session.Status)
+	})
+}
+
+// Fetches the latest revision of the custom resource and updates its status, retrying on conflicts
+func (controller *Controller) updateStatus(ctx context.Context, req request, mutate func(*unstructured.Unstructured) error) error {
+	client := controller.Dynamic.Resource(req.Resource).Namespace(req.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := client.Get(ctx, req.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := mutate(obj); err != nil {
+			return err
+		}
+		_, err = client.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Replaces the status of the unstructured object
+func setStatus(obj *unstructured.Unstructured, status interface{}) error {
+	converted, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(obj.Object, converted, "status")
+}
+
+// Parses the scenario using the same parser as the REST API and the CLI
+func parseScenario(scenario *ChaosScenario) (*k8x.TargetConfig, *k8x.RuntimeConfig, error) {
+	cfg := scenario.Config()
+
+	// Report every invalid field at once, with paths relative to the resource
+	if errs := parser.ValidateConfig(cfg); len(errs) > 0 {
+		for i := range errs {
+			errs[i].Field = "spec." + errs[i].Field
+		}
+		return nil, nil, errs
+	}
+
+	tc, err := parser.ParseTargetConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("spec.target: %w", err)
+	}
+
+	rc, err := parser.ParseRuntimeConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("spec.runtime: %w", err)
+	}
+
+	return tc, rc, nil
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"github.com/wizenheimer/cascade/internal/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// API Group of the custom resources
+const (
+	Group   = "cascade.dev"
+	Version = "v1alpha1"
+)
+
+// Resources watched by the controller
+var (
+	ScenarioResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "chaosscenarios"}
+	SessionResource  = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "chaossessions"}
+)
+
+// Phases of a ChaosSession
+const (
+	PhasePending   = "Pending"
+	PhaseRunning   = "Running"
+	PhaseCompleted = "Completed"
+	PhaseFailed    = "Failed"
+)
+
+// Condition types written back onto the custom resources
+const (
+	// The ChaosScenario parses into a valid target and runtime config
+	ConditionValid = "Valid"
+	// The ChaosSession is acting upon the cluster
+	ConditionRunning = "Running"
+	// The ChaosSession is done, successfully or not
+	ConditionCompleted = "Completed"
+)
+
+// ChaosScenario mirrors config.Config, the cluster being the one the controller runs in
+type ChaosScenario struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChaosScenarioSpec   `json:"spec"`
+	Status ChaosScenarioStatus `json:"status,omitempty"`
+}
+
+// Desired state of a ChaosScenario
+type ChaosScenarioSpec struct {
+	Description string         `json:"description,omitempty"`
+	Target      config.Target  `json:"target"`
+	Runtime     config.Runtime `json:"runtime,omitempty"`
+}
+
+// Observed state of a ChaosScenario
+type ChaosScenarioStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ChaosSession runs a ChaosScenario of the same namespace
+type ChaosSession struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ChaosSessionSpec   `json:"spec"`
+	Status ChaosSessionStatus `json:"status,omitempty"`
+}
+
+// Desired state of a ChaosSession
+type ChaosSessionSpec struct {
+	// Name of the ChaosScenario to run
+	ScenarioRef string `json:"scenarioRef"`
+	// Rounds to run, zero runs until the session is deleted
+	Rounds int `json:"rounds,omitempty"`
+	// Seeds victim selection, overriding the seed of the scenario
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// Observed state of a ChaosSession
+type ChaosSessionStatus struct {
+	ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+	Phase              string             `json:"phase,omitempty"`
+	Rounds             int                `json:"rounds,omitempty"`
+	Seed               int64              `json:"seed,omitempty"`
+	Victims            int                `json:"victims,omitempty"`
+	Failures           int                `json:"failures,omitempty"`
+	StartTime          *metav1.Time       `json:"startTime,omitempty"`
+	CompletionTime     *metav1.Time       `json:"completionTime,omitempty"`
+	Conditions         []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Converts the scenario onto the intermediate config shared with the REST API and the CLI
+func (scenario *ChaosScenario) Config() *config.Config {
+	return &config.Config{
+		Scenario: config.Scenario{
+			ID:          scenario.Namespace + "/" + scenario.Name,
+			Description: scenario.Spec.Description,
+		},
+		Target:  scenario.Spec.Target,
+		Runtime: scenario.Spec.Runtime,
+	}
+}
@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/rbac"
+	"github.com/wizenheimer/cascade/service/database"
+	"gorm.io/gorm"
+)
+
+// Requires the permission, regardless of the team
+func require(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		return permission, "", nil
+	}
+}
+
+// Requires the permission within the team passed as the :id path param
+func requireOnTeam(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		return permission, c.Param("id"), nil
+	}
+}
+
+// Requires the permission within the team passed as a query or form param
+func requireOnTeamParam(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		team, err := teamParam(c)
+		return permission, team, err
+	}
+}
+
+// Returns the team passed as a query or form param,
+// requests scoped to a team never fall back to being unscoped
+func teamParam(c echo.Context) (string, error) {
+	team := c.FormValue("team")
+	if team == "" {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "missing team")
+	}
+	return team, nil
+}
+
+// Requires the permission within the team owning the scenario,
+// identified by the :id path param or the scenario query param
+func (client *APIServer) requireOnScenario(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		scenarioID := c.Param("id")
+		if scenarioID == "" {
+			scenarioID = c.QueryParam("scenario")
+		}
+
+		versions, err := client.DB.GetScenarioByID(c.Request().Context(), scenarioID)
+		if err != nil {
+			return permission, "", err
+		}
+		if len(versions) == 0 {
+			return permission, "", &database.NotFoundError{Entity: "scenario", ID: scenarioID}
+		}
+
+		return permission, versions[0].TeamID, nil
+	}
+}
+
+// Requires the permission within the team owning the cluster passed as the :id path param
+func (client *APIServer) requireOnCluster(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		cluster, err := client.DB.GetClusterByID(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return permission, "", err
+		}
+		return permission, cluster.TeamID, nil
+	}
+}
+
+// Requires the permission within the team owning the session passed as the :id path param
+func (client *APIServer) requireOnSession(permission rbac.Permission) guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		session, err := client.DB.GetSessionByID(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return permission, "", err
+		}
+
+		scenario, err := client.DB.GetScenarioByIDByVersion(c.Request().Context(), session.ScenarioID, session.Version)
+		if err != nil {
+			return permission, "", err
+		}
+
+		return permission, scenario.TeamID, nil
+	}
+}
+
+// Requires the permission within the team owning the scenario if it exists,
+// falls back to the team passed as a form param for scenarios yet to be created
+func (client *APIServer) requireOnScenarioOrTeamParam(permission rbac.Permission) guard {
+	onScenario := client.requireOnScenario(permission)
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		permission, team, err := onScenario(c)
+		var notFound *database.NotFoundError
+		if errors.As(err, &notFound) {
+			team, err := teamParam(c)
+			return permission, team, err
+		}
+		return permission, team, err
+	}
+}
+
+// Requires the permission to run the scenario version passed as :scenario and :version path params,
+// destructive scenarios require more than dry runs
+func (client *APIServer) requireToRunScenario() guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil {
+			return "", "", echo.NewHTTPError(http.StatusBadRequest, "invalid version")
+		}
+
+		scenario, err := client.DB.GetScenarioByIDByVersion(c.Request().Context(), c.Param("scenario"), version)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", "", &database.NotFoundError{Entity: "scenario", ID: c.Param("scenario")}
+			}
+			return "", "", err
+		}
+
+		return rbac.SessionPermission(scenario.Mode), scenario.TeamID, nil
+	}
+}
+
+// Requires the permission to run the mode passed as a form param, within the team passed alongside
+func requireToRunMode() guard {
+	return func(c echo.Context) (rbac.Permission, string, error) {
+		team, err := teamParam(c)
+		return rbac.SessionPermission(c.FormValue("mode")), team, err
+	}
+}
@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/service/runner"
+)
+
+// Log entries replayed onto clients attaching to a running session
+const sessionBacklog = 100
+
+// Sessions running on the replica, keyed by session ID
+type liveSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*liveSession
+}
+
+func newLiveSessions() *liveSessions {
+	return &liveSessions{sessions: map[string]*liveSession{}}
+}
+
+func (ls *liveSessions) add(id string, session *liveSession) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.sessions[id] = session
+}
+
+func (ls *liveSessions) remove(id string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.sessions, id)
+}
+
+// Returns the session if it runs on the replica
+func (ls *liveSessions) get(id string) (*liveSession, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	session, ok := ls.sessions[id]
+	return session, ok
+}
+
+// Cancels every session running on the replica, waits for them to wind down unless the context ends first
+func (ls *liveSessions) stopAll(ctx context.Context) {
+	ls.mu.Lock()
+	sessions := make([]*liveSession, 0, len(ls.sessions))
+	for _, session := range ls.sessions {
+		sessions = append(sessions, session)
+	}
+	ls.mu.Unlock()
+
+	for _, session := range sessions {
+		session.run.Cancel()
+	}
+	for _, session := range sessions {
+		select {
+		case <-session.run.Done():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Session running on the replica, broadcasts its log entries onto every attached client
+type liveSession struct {
+	// Set once the session is prepared, ahead of running it
+	run *runner.Session
+
+	mu          sync.Mutex
+	backlog     []log.LogEntry
+	subscribers map[chan log.LogEntry]struct{}
+	closed      bool
+}
+
+func newLiveSession() *liveSession {
+	return &liveSession{subscribers: map[chan log.LogEntry]struct{}{}}
+}
+
+// Broadcasts the entry as a sink of the session.
+// Never holds up the session, entries are dropped for clients falling behind
+func (ls *liveSession) Write(entry log.LogEntry) error {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.backlog = append(ls.backlog, entry)
+	if len(ls.backlog) > sessionBacklog {
+		ls.backlog = ls.backlog[len(ls.backlog)-sessionBacklog:]
+	}
+	for subscriber := range ls.subscribers {
+		select {
+		case subscriber <- entry:
+		default:
+		}
+	}
+	return nil
+}
+
+// Returns the recent entries along with a channel receiving the later ones, closed once the session ends.
+// Every subscription must be cancelled
+func (ls *liveSession) subscribe() ([]log.LogEntry, <-chan log.LogEntry, func()) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	backlog := make([]log.LogEntry, len(ls.backlog))
+	copy(backlog, ls.backlog)
+
+	entries := make(chan log.LogEntry, sessionBacklog)
+	if ls.closed {
+		close(entries)
+		return backlog, entries, func() {}
+	}
+	ls.subscribers[entries] = struct{}{}
+
+	return backlog, entries, func() {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		if _, ok := ls.subscribers[entries]; ok {
+			delete(ls.subscribers, entries)
+			close(entries)
+		}
+	}
+}
+
+// Ends every subscription, once the session has ended
+func (ls *liveSession) close() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.closed = true
+	for subscriber := range ls.subscribers {
+		delete(ls.subscribers, subscriber)
+		close(subscriber)
+	}
+}
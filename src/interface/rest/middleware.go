@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/auth"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/rbac"
+	"github.com/wizenheimer/cascade/service/database"
+	"gorm.io/gorm"
+)
+
+// Keys under which the caller's identity is stored onto Echo's Context
+const (
+	userContextKey       = "user"       // Authenticated *models.User
+	teamContextKey       = "team"       // Team the API Key is scoped to, if any
+	claimContextKey      = "claim"      // Claims of the session token, if any
+	authorizedContextKey = "authorized" // Team the request was authorized within, if any
+)
+
+// Header carrying API Keys for clients which can't set the Authorization header
+const apiKeyHeader = "X-API-Key"
+
+// Authenticates the request using either a session token or an API Key
+// and attaches the caller onto Echo's Context
+func (client *APIServer) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context()
+
+		credential := c.Request().Header.Get(apiKeyHeader)
+		if credential == "" {
+			credential = strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		}
+		if credential == "" {
+			return c.JSON(http.StatusUnauthorized, "missing credentials")
+		}
+
+		var userID string
+		if auth.IsAPIKey(credential) {
+			key, err := client.DB.GetAPIKeyByHash(ctx, auth.HashAPIKey(credential))
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, auth.ErrInvalidCredentials.Error())
+			}
+			if key.TeamID != nil {
+				c.Set(teamContextKey, *key.TeamID)
+			}
+			userID = key.UserID
+		} else {
+			claims, err := client.Tokens.Parse(credential)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, err.Error())
+			}
+			revoked, err := client.DB.IsTokenRevoked(ctx, claims.ID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, err)
+			}
+			if revoked {
+				return c.JSON(http.StatusUnauthorized, auth.ErrInvalidToken.Error())
+			}
+			c.Set(claimContextKey, claims)
+			userID = claims.Subject
+		}
+
+		user, err := client.DB.GetUserByID(ctx, userID)
+		if err != nil || !user.IsActive {
+			return c.JSON(http.StatusUnauthorized, auth.ErrInvalidCredentials.Error())
+		}
+		c.Set(userContextKey, user)
+
+		return next(c)
+	}
+}
+
+// Returns the authenticated caller, nil if anonymous
+func caller(c echo.Context) *models.User {
+	user, _ := c.Get(userContextKey).(*models.User)
+	return user
+}
+
+// Returns the ID of the user making the request, empty if anonymous
+func actor(c echo.Context) string {
+	if user := caller(c); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+// Returns the claims of the session token used for the request, nil if authenticated otherwise
+func claims(c echo.Context) *jwt.RegisteredClaims {
+	claims, _ := c.Get(claimContextKey).(*jwt.RegisteredClaims)
+	return claims
+}
+
+// Resolves the permission required by the request along with the team it's scoped to
+// An empty team leaves the request unscoped, in which case only the caller's role is checked
+// and API Keys scoped to a team are turned away,
+// guards of team scoped routes return an error rather than an empty team
+type guard func(c echo.Context) (rbac.Permission, string, error)
+
+// Authorizes the request as per the caller's role and their team membership
+func (client *APIServer) authorize(resolve guard) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user := caller(c)
+			if user == nil {
+				return c.JSON(http.StatusUnauthorized, "missing credentials")
+			}
+
+			permission, team, err := resolve(c)
+			if err != nil {
+				return guardError(c, err)
+			}
+
+			if team == "" {
+				// API Keys scoped to a team never reach beyond it, routes open to them skip authorization altogether
+				if _, ok := c.Get(teamContextKey).(string); ok {
+					return c.JSON(http.StatusForbidden, "api key is scoped to a team")
+				}
+				if !rbac.Allowed(user.Role, permission) {
+					return c.JSON(http.StatusForbidden, fmt.Sprintf("missing permission %s", permission))
+				}
+				return next(c)
+			}
+
+			// API Keys scoped to a team never reach beyond it
+			if scope, ok := c.Get(teamContextKey).(string); ok && scope != team {
+				return c.JSON(http.StatusForbidden, "api key is not scoped to this team")
+			}
+
+			if rbac.Unscoped(user.Role) {
+				c.Set(authorizedContextKey, team)
+				return next(c)
+			}
+
+			membership, err := client.DB.GetMembership(c.Request().Context(), team, user.ID)
+			if err != nil {
+				var notFound *database.NotFoundError
+				if errors.As(err, &notFound) {
+					return c.JSON(http.StatusForbidden, "not a member of this team")
+				}
+				return c.JSON(http.StatusInternalServerError, err)
+			}
+
+			if !rbac.AllowedWithin(user.Role, membership.Role, permission) {
+				return c.JSON(http.StatusForbidden, fmt.Sprintf("missing permission %s", permission))
+			}
+
+			c.Set(authorizedContextKey, team)
+			return next(c)
+		}
+	}
+}
+
+// Returns the team the request was authorized within, empty for unscoped requests
+func authorizedTeam(c echo.Context) string {
+	team, _ := c.Get(authorizedContextKey).(string)
+	return team
+}
+
+// Renders errors raised while resolving guards
+func guardError(c echo.Context, err error) error {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	var notFound *database.NotFoundError
+	if errors.As(err, &notFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.JSON(http.StatusInternalServerError, err)
+}
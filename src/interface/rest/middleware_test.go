@@ -0,0 +1,131 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/wizenheimer/cascade/internal/models"
+)
+
+func TestUpdateScenarioTeam(t *testing.T) {
+	admin := testServer(t)
+	owner := admin.signUp("owner@example.com", "manager")
+
+	var checkout, payments models.Team
+	owner.form(http.MethodPost, "/team", url.Values{"name": {"checkout"}}, http.StatusCreated, &checkout)
+	owner.form(http.MethodPost, "/team", url.Values{"name": {"payments"}}, http.StatusCreated, &payments)
+
+	var existing models.Scenario
+	owner.multipart(http.MethodPost, "/scenario", url.Values{"team": {checkout.ID}}, "config", []byte(testScenario), http.StatusOK, &existing)
+
+	tests := []struct {
+		name     string
+		scenario string
+		team     string
+		want     string
+	}{
+		// Unknown scenarios are created within the team passed along
+		{name: "created", scenario: "unknown", team: payments.ID, want: payments.ID},
+		// Existing scenarios stay within their team, whichever one is passed along
+		{name: "updated", scenario: existing.ID, team: payments.ID, want: checkout.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := &testClient{t: t, url: owner.url, token: owner.token}
+
+			var updated models.Scenario
+			owner.multipart(http.MethodPatch, "/scenario/"+tt.scenario, url.Values{"team": {tt.team}}, "config", []byte(testScenario), http.StatusOK, &updated)
+			if updated.TeamID != tt.want {
+				t.Fatalf("PATCH /scenario/%s = team %q, want %q", tt.scenario, updated.TeamID, tt.want)
+			}
+
+			var versions []models.Scenario
+			owner.do(http.MethodGet, "/scenario/"+updated.ID, "", nil, http.StatusOK, &versions)
+			if len(versions) == 0 || versions[0].TeamID != tt.want {
+				t.Fatalf("GET /scenario/%s = %+v, want team %q", updated.ID, versions, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeScopedKeys(t *testing.T) {
+	admin := testServer(t)
+	owner := admin.signUp("owner@example.com", "manager")
+
+	var checkout, payments models.Team
+	owner.form(http.MethodPost, "/team", url.Values{"name": {"checkout"}}, http.StatusCreated, &checkout)
+	owner.form(http.MethodPost, "/team", url.Values{"name": {"payments"}}, http.StatusCreated, &payments)
+
+	// Issues an API Key for the client, scoped to the team if set
+	key := func(tc *testClient, team string) string {
+		var res APIKeyResponse
+		tc.form(http.MethodPost, "/auth/keys", url.Values{"name": {"ci"}, "team": {team}}, http.StatusCreated, &res)
+		return res.Key
+	}
+	scoped, unscoped := key(owner, checkout.ID), key(owner, "")
+
+	// Keys are only scoped to the teams of their user, admins included
+	admin.form(http.MethodPost, "/team/"+checkout.ID+"/users", url.Values{"email": {"admin@example.com"}, "role": {"member"}}, http.StatusOK, nil)
+	adminScoped := key(admin, checkout.ID)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		values url.Values
+		want   int
+	}{
+		{name: "scoped key within its team", key: scoped, method: http.MethodGet, path: "/scenario?team=" + checkout.ID, want: http.StatusOK},
+		{name: "scoped key beyond its team", key: scoped, method: http.MethodGet, path: "/scenario?team=" + payments.ID, want: http.StatusForbidden},
+		{name: "scoped key on an unscoped route", key: scoped, method: http.MethodPost, path: "/team", values: url.Values{"name": {"search"}}, want: http.StatusForbidden},
+		{name: "scoped admin key on an unscoped route", key: adminScoped, method: http.MethodGet, path: "/audit", want: http.StatusForbidden},
+		{name: "unscoped key on an unscoped route", key: unscoped, method: http.MethodPost, path: "/team", values: url.Values{"name": {"search"}}, want: http.StatusCreated},
+		{name: "scoped key on a route skipping authorization", key: scoped, method: http.MethodGet, path: "/auth/keys", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testClient{t: t, url: owner.url, token: tt.key}
+			client.form(tt.method, tt.path, tt.values, tt.want, nil)
+		})
+	}
+}
+
+func TestAuthorizeTeamRoles(t *testing.T) {
+	admin := testServer(t)
+	manager := admin.signUp("manager@example.com", "manager")
+	owner := admin.signUp("owner@example.com", "user")
+	member := admin.signUp("member@example.com", "user")
+	outsider := admin.signUp("outsider@example.com", "manager")
+
+	var team models.Team
+	manager.form(http.MethodPost, "/team", url.Values{"name": {"checkout"}}, http.StatusCreated, &team)
+	manager.form(http.MethodPost, "/team/"+team.ID+"/users", url.Values{"email": {"owner@example.com"}, "role": {"owner"}}, http.StatusOK, nil)
+	manager.form(http.MethodPost, "/team/"+team.ID+"/users", url.Values{"email": {"member@example.com"}, "role": {"member"}}, http.StatusOK, nil)
+
+	tests := []struct {
+		name   string
+		client *testClient
+		method string
+		path   string
+		want   int
+	}{
+		// Managers manage the teams they belong to through their role
+		{name: "manager manages", client: manager, method: http.MethodPatch, path: "/team/" + team.ID, want: http.StatusOK},
+		// Owners manage their team through their membership, whichever their role
+		{name: "owner manages", client: owner, method: http.MethodPatch, path: "/team/" + team.ID, want: http.StatusOK},
+		{name: "member reads", client: member, method: http.MethodGet, path: "/team/" + team.ID + "/users", want: http.StatusOK},
+		{name: "member manages", client: member, method: http.MethodPatch, path: "/team/" + team.ID, want: http.StatusForbidden},
+		// Roles only apply within the teams a user belongs to
+		{name: "non-member reads", client: outsider, method: http.MethodGet, path: "/team/" + team.ID + "/users", want: http.StatusForbidden},
+		{name: "non-member manages", client: outsider, method: http.MethodPatch, path: "/team/" + team.ID, want: http.StatusForbidden},
+		{name: "admin manages", client: admin, method: http.MethodPatch, path: "/team/" + team.ID, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testClient{t: t, url: tt.client.url, token: tt.client.token}
+			client.form(tt.method, tt.path, url.Values{"description": {tt.name}}, tt.want, nil)
+		})
+	}
+}
@@ -1,17 +1,14 @@
 package rest
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	log "github.com/wizenheimer/cascade/internal/logger"
 	"github.com/wizenheimer/cascade/internal/parser"
+	"github.com/wizenheimer/cascade/internal/rbac"
 	k8x "github.com/wizenheimer/cascade/service/kubernetes"
-	"go.uber.org/zap"
+	"github.com/wizenheimer/cascade/service/runner"
 )
 
 // Inject routes onto the instance
@@ -19,68 +16,90 @@ func (rest *APIServer) injectRoutes(e *echo.Echo) {
 	// =======================
 	//       QuickStart
 	// =======================
-	e.POST("/quickstart", QuickStart) // QuickStart Endpoint for Stateless Runs
+	e.POST("/quickstart", rest.QuickStart, rest.authenticate, rest.authorize(requireToRunMode())) // QuickStart Endpoint for Stateless Runs
 	// =======================
 	//       SCENARIO
 	// =======================
-	scenario := e.Group("/scenario")
-	scenario.POST("", rest.CreateScenario)      // Create a scenario
-	scenario.GET("", rest.ListScenario)         // List out all scenarios for the given team
-	scenario.GET("/:id", rest.DetailScenario)   // List out properties of the scenario
-	scenario.PATCH("/:id", rest.UpdateScenario) // Update properties of the scenario
+	scenario := e.Group("/scenario", rest.authenticate)
+	scenario.POST("", rest.CreateScenario, rest.authorize(requireOnTeamParam(rbac.WriteScenario)))                       // Create a scenario
+	scenario.POST("/validate", rest.ValidateScenario)                                                                    // Validate a scenario without persisting it
+	scenario.GET("", rest.ListScenario, rest.authorize(requireOnTeamParam(rbac.ReadScenario)))                           // List out all scenarios for the given team
+	scenario.GET("/:id", rest.DetailScenario, rest.authorize(rest.requireOnScenario(rbac.ReadScenario)))                 // List out properties of the scenario
+	scenario.GET("/:id/:version", rest.DetailScenarioVersion, rest.authorize(rest.requireOnScenario(rbac.ReadScenario))) // List out every property of the scenario version
+	scenario.GET("/:id/:version/plan", rest.PlanScenario, rest.authorize(rest.requireOnScenario(rbac.RunDryRun)))        // Preview the pods a session would act upon
+	scenario.PATCH("/:id", rest.UpdateScenario, rest.authorize(rest.requireOnScenarioOrTeamParam(rbac.WriteScenario)))   // Update properties of the scenario
 
 	// =======================
 	//       SESSION
 	// =======================
-	session := e.Group("/session")
-	session.POST("/:scenario/:version", rest.CreateSession) // Trigger Chaos Experiment and Stream Logs via SSE
+	session := e.Group("/session", rest.authenticate)
+	session.POST("/:scenario/:version", rest.CreateSession, rest.authorize(rest.requireToRunScenario()))        // Trigger Chaos Experiment across one or more clusters and Stream Logs via SSE
+	session.GET("/:id/report", rest.GetSessionReport, rest.authorize(rest.requireOnSession(rbac.ReadScenario))) // Combined report along with the status of every cluster
+	session.GET("", rest.ListSessions, rest.authorize(rest.requireOnScenario(rbac.ReadScenario)))               // List out the sessions of the scenario passed as a query param
+	session.GET("/:id/logs", rest.AttachSession, rest.authorize(rest.requireOnSession(rbac.ReadScenario)))      // Attach to a running session and Stream its Logs via SSE
+	session.POST("/:id/stop", rest.StopSession, rest.authorize(rest.requireOnSession(rbac.KillSession)))        // Stop a running session
 
 	// =======================
 	//      METRIC
 	// =======================
-	metric := e.Group("/metric")
-	metric.GET("", rest.GetMetrics) // Get Metrics for the given Scenario via Query Params
+	metric := e.Group("/metric", rest.authenticate)
+	metric.GET("", rest.GetMetrics, rest.authorize(rest.requireOnScenario(rbac.ReadScenario))) // Get Metrics for the given Scenario via Query Params
 
 	// =======================
 	//      TEAM
 	// =======================
-	team := e.Group("/team")
-	team.POST("", rest.CreateTeam)            // Create a Team
-	team.DELETE("/:id", rest.DeleteTeam)      // Delete a Team
-	team.GET("/:id/users", rest.ListUsers)    // List Team Users
-	team.PATCH("/:id", rest.ManageTeam)       // Implement Team Attribute Management
-	team.POST("/:id/users", rest.ManageUsers) // Implement User Management
+	team := e.Group("/team", rest.authenticate)
+	team.POST("", rest.CreateTeam, rest.authorize(require(rbac.CreateTeam)))                          // Create a Team
+	team.DELETE("/:id", rest.DeleteTeam, rest.authorize(requireOnTeam(rbac.ManageTeam)))              // Delete a Team
+	team.GET("/:id/users", rest.ListUsers, rest.authorize(requireOnTeam(rbac.ReadTeam)))              // List Team Users
+	team.PATCH("/:id", rest.ManageTeam, rest.authorize(requireOnTeam(rbac.ManageTeam)))               // Implement Team Attribute Management
+	team.POST("/:id/users", rest.ManageUsers, rest.authorize(requireOnTeam(rbac.ManageTeam)))         // Implement User Management
+	team.DELETE("/:id/users/:email", rest.RemoveUser, rest.authorize(requireOnTeam(rbac.ManageTeam))) // Remove a User from the Team
+
+	// =======================
+	//      CLUSTER
+	// =======================
+	cluster := e.Group("/cluster", rest.authenticate)
+	cluster.POST("", rest.RegisterCluster, rest.authorize(requireOnTeamParam(rbac.ManageCluster)))        // Register a Cluster for the given team
+	cluster.GET("", rest.ListClusters, rest.authorize(requireOnTeamParam(rbac.ReadCluster)))              // List out Clusters for the given team
+	cluster.GET("/:id", rest.DetailCluster, rest.authorize(rest.requireOnCluster(rbac.ReadCluster)))      // List out properties of the Cluster
+	cluster.PATCH("/:id", rest.UpdateCluster, rest.authorize(rest.requireOnCluster(rbac.ManageCluster)))  // Update the Cluster or rotate its Credentials
+	cluster.DELETE("/:id", rest.DeleteCluster, rest.authorize(rest.requireOnCluster(rbac.ManageCluster))) // Remove the Cluster from the registry
+
+	// =======================
+	//      AUDIT
+	// =======================
+	audit := e.Group("/audit", rest.authenticate)
+	audit.GET("", rest.ListAudit, rest.authorize(require(rbac.ReadAudit))) // List out Audit Entries filtered via Query Params
+
+	// =======================
+	//      ROLE
+	// =======================
+	user := e.Group("/user", rest.authenticate)
+	user.PATCH("/:email/role", rest.UpdateRole, rest.authorize(require(rbac.ManageUsers))) // Change the Role of a User
 
 	// =======================
 	//     USER
 	// =======================
 	auth := e.Group("/auth")
-	auth.POST("", rest.SignUp)            // Sign Up a User
-	auth.POST("/:id", rest.Login)         // Login a User
-	auth.POST("/:id/logout", rest.Logout) // Logout a User
-	auth.POST("/:id/delete", rest.Churn)  // Delete a User
+	auth.POST("", rest.SignUp)                                      // Sign Up a User
+	auth.POST("/:id", rest.Login)                                   // Login a User
+	auth.POST("/:id/logout", rest.Logout, rest.authenticate)        // Logout a User
+	auth.POST("/:id/delete", rest.Churn, rest.authenticate)         // Delete a User
+	auth.POST("/keys", rest.CreateAPIKey, rest.authenticate)        // Issue an API Key
+	auth.GET("/keys", rest.ListAPIKeys, rest.authenticate)          // List out API Keys
+	auth.DELETE("/keys/:key", rest.RevokeAPIKey, rest.authenticate) // Revoke an API Key
 }
 
 // QuickStart is the handler for the QuickStart endpoint
-func QuickStart(c echo.Context) error {
+func (client *APIServer) QuickStart(c echo.Context) error {
+	// Stateless runs reach the cluster through the server's own credentials
+	if !rbac.Unscoped(caller(c).Role) {
+		return c.JSON(http.StatusForbidden, errServerCredentials.Error())
+	}
+
 	// Set Headers
-	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().WriteHeader(http.StatusOK)
-
-	// Inject Loggers with Hook
-	loggerWithChan := log.LoggerPool.Get().(*log.LoggerWithChannel)
-	defer func() {
-		// Clear the channel before putting the logger back in the pool
-		for len(loggerWithChan.LogChan) > 0 {
-			<-loggerWithChan.LogChan
-		}
-		log.LoggerPool.Put(loggerWithChan)
-	}()
-
-	logger := loggerWithChan.Logger
-	logChan := loggerWithChan.LogChan
+	setEventStreamHeaders(c)
 
 	sessionID := c.Request().Header.Get("X-Request-ID")
 	if sessionID == "" {
@@ -92,91 +111,54 @@ func QuickStart(c echo.Context) error {
 		scenario = "undefined"
 	}
 
-	// Create a new context that will be cancelled when the request is done
-	ctx, cancel := context.WithCancel(c.Request().Context())
-	defer cancel()
-
 	// Parse Configs
 	cc, tc, rc, err := parser.ParseConfigsFromContext(c)
 	if err != nil {
-		// Parse the log
-		data, err := log.ParseLog("error", err.Error())
-		if err != nil {
-			return err
-		}
-
-		// Send response back to client
-		_, err = c.Response().Write(data)
-		if err != nil {
-			return err
-		}
-
-		c.Response().Flush()
-		return nil
+		return writeError(c, err)
+	}
+
+	name := cc.Context
+	if name == "" {
+		name = "default"
 	}
 
-	// Create executor
-	executor, err := k8x.CreateExecutor(cc, tc, rc, logger)
+	// Stream logs back to the client
+	sink := runner.SinkFunc(func(entry log.LogEntry) error {
+		return writeLogEntry(c, entry)
+	})
+
+	// Stateless runs go on until the client disconnects
+	run, err := runner.New(runner.Config{
+		ID:       sessionID,
+		Scenario: scenario,
+		Targets:  []runner.Target{{Name: name, Config: cc}},
+		Target:   tc,
+		Runtime:  rc,
+		FanOut:   &k8x.FanOutConfig{Strategy: k8x.Parallel},
+	}, client.Logger, runner.Hooks{}, sink)
 	if err != nil {
-		// Parse the log
-		data, err := log.ParseLog("error", err.Error())
-		if err != nil {
-			return err
-		}
-
-		// Send response back to client
-		_, err = c.Response().Write(data)
-		if err != nil {
-			return err
-		}
-
-		c.Response().Flush()
-		return nil
+		return writeError(c, err)
 	}
 
-	// Create ticker
-	ticker := time.NewTicker(rc.Interval)
-	defer ticker.Stop()
-
-	// Start processing in a goroutine
-	go func(executor *k8x.Executor, sessionID string, ctx context.Context, next <-chan time.Time) {
-		for {
-			executor.Logger.Info("Chaos Session Triggered", zap.Any("Session", sessionID))
-			executor.Logger.Info("Chaos Scenario", zap.Any("Scenario", scenario))
-
-			// Trigger Execution
-			if err = executor.Execute(ctx); err != nil {
-				executor.Logger.Error(err.Error())
-			}
-
-			select {
-			case <-next:
-				// trigger next session
-			case <-ctx.Done():
-				// skip subsequent execution
-				return
-			}
-		}
-	}(executor, sessionID, ctx, ticker.C)
+	run.Run(c.Request().Context())
+	client.Logger.Info("Client disconnected, stopped log stream", log.Session(sessionID), log.Event(log.EventSessionEnded))
+	return nil
+}
 
-	// Stream logs back to the client
-	for {
-		select {
-		case logEntry := <-logChan:
-			// Serialize logEntry to JSON
-			data, err := json.Marshal(logEntry)
-			if err != nil {
-				continue
-			}
-			// Send log entry to client
-			_, err = c.Response().Write([]byte(fmt.Sprintf("data: %s\n\n", data)))
-			if err != nil {
-				return err
-			}
-			c.Response().Flush()
-		case <-ctx.Done():
-			logger.Info("Client disconnected, stopping log stream")
-			return nil
-		}
+// Sends an error back to the client as a server sent event
+func writeError(c echo.Context, err error) error {
+	// Parse the log
+	data, err := log.ParseLog("error", err.Error())
+	if err != nil {
+		return err
 	}
+
+	// Send response back to client
+	_, err = c.Response().Write(data)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Flush()
+	return nil
 }
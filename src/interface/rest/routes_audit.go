@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/models"
+)
+
+// Appends an entry onto the audit log for the current request
+// Recorded even if the client has already disconnected
+func (client *APIServer) audit(c echo.Context, action, targetType, targetID, diff string) error {
+	return client.auditFrom(context.WithoutCancel(c.Request().Context()), origin(c), action, targetType, targetID, diff)
+}
+
+// Appends an entry onto the audit log on behalf of a request, which may have already been served
+func (client *APIServer) auditFrom(ctx context.Context, from requestOrigin, action, targetType, targetID, diff string) error {
+	_, err := client.DB.CreateAuditEntry(ctx, &models.AuditEntry{
+		ActorID:    from.Actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Diff:       diff,
+		RequestID:  from.RequestID,
+	})
+	return err
+}
+
+// Identifies the caller and the request an action originates from, outlives the request itself
+type requestOrigin struct {
+	Actor     string
+	RequestID string
+}
+
+func origin(c echo.Context) requestOrigin {
+	return requestOrigin{
+		Actor:     actor(c),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+}
+
+func (client *APIServer) ListAudit(c echo.Context) error {
+	// Filter Audit Entries by means of Query Params
+	filter := models.AuditFilter{
+		ActorID:    c.QueryParam("actor"),
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target"),
+		RequestID:  c.QueryParam("request_id"),
+	}
+
+	var err error
+	if since := c.QueryParam("since"); since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+	if until := c.QueryParam("until"); until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	entries, err := client.DB.ListAuditEntries(c.Request().Context(), filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,339 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/audit"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/parser"
+	"github.com/wizenheimer/cascade/internal/rbac"
+	"github.com/wizenheimer/cascade/service/database"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+)
+
+var errRegistryDisabled = errors.New("cluster registry is disabled, CLUSTER_SECRET_KEY is not set")
+
+var errServerCredentials = errors.New("only admins may target clusters through the server's own credentials, pass a registered cluster instead")
+
+func (client *APIServer) RegisterCluster(c echo.Context) error {
+	// Register a Cluster along with its Credentials, owned by the given Team
+	if client.Sealer == nil {
+		return c.JSON(http.StatusServiceUnavailable, errRegistryDisabled.Error())
+	}
+
+	var req ClusterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if strings.TrimSpace(req.Name) == "" || req.Team == "" {
+		return c.JSON(http.StatusBadRequest, "name and team are required")
+	}
+	if err := validateServer(req.Server); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	creds, err := parseClusterCredentials(c, &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if creds == nil {
+		return c.JSON(http.StatusBadRequest, "either a kubeconfig or a token is required")
+	}
+
+	sealed, err := parser.SealClusterCredentials(creds, client.Sealer)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	cluster, err := client.DB.CreateCluster(c.Request().Context(), &models.Cluster{
+		Name:        strings.TrimSpace(req.Name),
+		Server:      req.Server,
+		Credentials: sealed,
+		TeamID:      req.Team,
+	})
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	diff, err := audit.Diff(models.Cluster{}, cluster)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditClusterCreate, models.AuditTargetCluster, cluster.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusCreated, cluster)
+}
+
+func (client *APIServer) ListClusters(c echo.Context) error {
+	// List out Clusters of the owning Team by means of Query Params
+	team := c.QueryParam("team")
+	if team == "" {
+		return c.JSON(http.StatusBadRequest, "team is required")
+	}
+
+	clusters, err := client.DB.ListClusters(c.Request().Context(), team)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, clusters)
+}
+
+func (client *APIServer) DetailCluster(c echo.Context) error {
+	// List out properties of the Cluster, Credentials are never handed out
+	cluster, err := client.DB.GetClusterByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, cluster)
+}
+
+func (client *APIServer) UpdateCluster(c echo.Context) error {
+	// Rename the Cluster, move its API Server or rotate its Credentials
+	if client.Sealer == nil {
+		return c.JSON(http.StatusServiceUnavailable, errRegistryDisabled.Error())
+	}
+
+	var req ClusterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if req.Server != "" {
+		if err := validateServer(req.Server); err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	ctx := c.Request().Context()
+	previous, err := client.DB.GetClusterByID(ctx, c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	updated := &models.Cluster{
+		Name:   strings.TrimSpace(req.Name),
+		Server: req.Server,
+	}
+
+	creds, err := parseClusterCredentials(c, &req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if creds != nil {
+		updated.Credentials, err = parser.SealClusterCredentials(creds, client.Sealer)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, err)
+		}
+	}
+
+	cluster, err := client.DB.UpdateCluster(ctx, previous.ID, updated)
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	diff, err := audit.Diff(previous, cluster)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditClusterUpdate, models.AuditTargetCluster, cluster.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, cluster)
+}
+
+func (client *APIServer) DeleteCluster(c echo.Context) error {
+	// Remove the Cluster from the registry
+	cluster, err := client.DB.DeleteCluster(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	if err := client.audit(c, models.AuditClusterDelete, models.AuditTargetCluster, cluster.ID, ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Parse the credentials passed alongside the request, nil if none were passed
+func parseClusterCredentials(c echo.Context, req *ClusterRequest) (*k8x.ClusterCredentials, error) {
+	kubeconfig, err := readFormFile(c, "kubeconfig")
+	if err != nil {
+		return nil, err
+	}
+	ca, err := readFormFile(c, "ca")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kubeconfig) == 0 && req.Token == "" {
+		return nil, nil
+	}
+
+	creds := &k8x.ClusterCredentials{
+		Kubeconfig: kubeconfig,
+		Context:    req.Context,
+		Token:      req.Token,
+		CAData:     ca,
+		Insecure:   req.Insecure,
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// Reads an optional multipart file, nil if it wasn't passed
+func readFormFile(c echo.Context, name string) ([]byte, error) {
+	file, err := c.FormFile(name)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return io.ReadAll(src)
+}
+
+// Validates the address of an API Server
+func validateServer(server string) error {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return errors.New("server must be an http(s) url")
+	}
+	return nil
+}
+
+// Cluster targeted by a session
+type clusterTarget struct {
+	// Registered cluster ID or kube context, identifies the cluster in reports
+	Name string
+	// Registered cluster ID, empty for clusters reached via the kubeconfig
+	ID     string
+	Config *k8x.ClusterConfig
+}
+
+// Maps errors resolving cluster targets onto their status codes
+func clusterTargetError(c echo.Context, err error) error {
+	if errors.Is(err, errRegistryDisabled) {
+		return c.JSON(http.StatusServiceUnavailable, err.Error())
+	}
+	if errors.Is(err, errServerCredentials) {
+		return c.JSON(http.StatusForbidden, err.Error())
+	}
+	var notFound *database.NotFoundError
+	if errors.As(err, &notFound) {
+		return c.JSON(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusUnprocessableEntity, err.Error())
+}
+
+// Resolves the Clusters targeted by a session: the registered clusters or the kube contexts passed as form params,
+// the cluster referenced by the scenario otherwise, falls back to the kubeconfig and master form params
+// Kube contexts and the fallback reach clusters through the server's own credentials, which only admins may use
+func (client *APIServer) resolveClusterTargets(c echo.Context, scenario models.Scenario) ([]clusterTarget, error) {
+	clusterIDs, err := formValues(c, "cluster")
+	if err != nil {
+		return nil, err
+	}
+	contexts, err := formValues(c, "context")
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterIDs) == 0 && len(contexts) == 0 && scenario.ClusterID != "" {
+		clusterIDs = []string{scenario.ClusterID}
+	}
+
+	// Every cluster is targeted at most once
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, clusterIDs...), contexts...) {
+		if seen[name] {
+			return nil, fmt.Errorf("cluster %q is targeted more than once", name)
+		}
+		seen[name] = true
+	}
+
+	var targets []clusterTarget
+	for _, clusterID := range clusterIDs {
+		cc, err := client.resolveRegisteredCluster(c, scenario, clusterID)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, clusterTarget{Name: clusterID, ID: clusterID, Config: cc})
+	}
+
+	if (len(contexts) > 0 || len(clusterIDs) == 0) && !rbac.Unscoped(caller(c).Role) {
+		return nil, errServerCredentials
+	}
+
+	for _, context := range contexts {
+		cc, err := parser.ParseClusterConfigFromContext(c)
+		if err != nil {
+			return nil, err
+		}
+		cc.Context = context
+		targets = append(targets, clusterTarget{Name: context, Config: cc})
+	}
+
+	if len(targets) == 0 {
+		cc, err := parser.ParseClusterConfigFromContext(c)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, clusterTarget{Name: "default", Config: cc})
+	}
+
+	return targets, nil
+}
+
+// Resolves the Cluster Config of a registered cluster, only clusters owned by the scenario's team are visible
+func (client *APIServer) resolveRegisteredCluster(c echo.Context, scenario models.Scenario, clusterID string) (*k8x.ClusterConfig, error) {
+	if client.Sealer == nil {
+		return nil, errRegistryDisabled
+	}
+
+	cluster, err := client.DB.GetClusterByID(c.Request().Context(), clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.TeamID != scenario.TeamID {
+		return nil, &database.NotFoundError{Entity: "cluster", ID: clusterID}
+	}
+
+	return parser.ParseRegisteredCluster(cluster, client.Sealer, c.FormValue("healthcheck"))
+}
+
+// Returns every value of a form param, comma separated values are split apart
+func formValues(c echo.Context, name string) ([]string, error) {
+	params, err := c.FormParams()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, param := range params[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values, nil
+}
@@ -7,7 +7,7 @@ import (
 )
 
 func (client *APIServer) GetMetrics(c echo.Context) error {
-	scenarioStr := c.Param("scenario")
+	scenarioStr := c.QueryParam("scenario")
 	metrics, err := client.DB.GetSessionMetrics(c.Request().Context(), scenarioStr)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err)
@@ -1,55 +1,64 @@
 package rest
 
 import (
-	"io"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
-	"github.com/wizenheimer/cascade/internal/config"
+	"github.com/wizenheimer/cascade/internal/audit"
+	"github.com/wizenheimer/cascade/internal/models"
 	"github.com/wizenheimer/cascade/internal/parser"
-	"gopkg.in/yaml.v2"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func (client *APIServer) CreateScenario(c echo.Context) error {
-	// Create a Scenario using YAML
-	var config config.Config
+	// Create a Scenario using YAML, owned by the given Team
+	team := c.FormValue("team")
+	if team == "" {
+		return c.JSON(http.StatusBadRequest, "team is required")
+	}
 
-	// Handle file upload
-	file, err := c.FormFile("config")
+	data, err := readFormFile(c, "config")
 	if err != nil {
 		return err
 	}
+	if data == nil {
+		return c.JSON(http.StatusBadRequest, "config is required")
+	}
 
-	src, err := file.Open()
-	if err != nil {
-		return err
+	// Reject invalid scenarios before they're persisted
+	config, errs := parser.ValidateYAML(data)
+	if len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, ValidationResult{Errors: errs})
 	}
-	defer src.Close()
 
-	// Read and parse YAML file
-	data, err := io.ReadAll(src)
+	// Parse the Config
+	scenario, err := parser.ParseYAMLConfigToScenario(config)
 	if err != nil {
 		return err
 	}
+	scenario.TeamID = team
 
-	err = yaml.Unmarshal(data, &config)
+	// Persis the Scenario
+	created, err := client.DB.CreateScenario(c.Request().Context(), scenario)
 	if err != nil {
 		return err
 	}
 
-	// Parse the Config
-	scenario, err := parser.ParseYAMLConfigToScenario(&config)
+	// Record the Scenario in the audit log
+	diff, err := audit.Diff(models.Scenario{}, created)
 	if err != nil {
 		return err
 	}
-
-	// Persis the Scenario
-	_, err = client.DB.CreateScenario(c.Request().Context(), scenario)
+	err = client.audit(c, models.AuditScenarioCreate, models.AuditTargetScenario, created.ID, diff)
 	if err != nil {
 		return err
 	}
 
-	return c.NoContent(http.StatusOK) // TODO
+	return c.JSON(http.StatusOK, created)
 }
 
 func (client *APIServer) ListScenario(c echo.Context) error {
@@ -77,42 +86,139 @@ func (client *APIServer) DetailScenario(c echo.Context) error {
 	return c.JSON(http.StatusOK, scenario)
 }
 
+// Returns every property of the scenario version, such as its targets and runtime
+func (client *APIServer) DetailScenarioVersion(c echo.Context) error {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid version")
+	}
+
+	scenario, err := client.DB.GetScenarioByIDByVersion(c.Request().Context(), c.Param("id"), version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, scenario)
+}
+
 func (client *APIServer) UpdateScenario(c echo.Context) error {
 	// Handle file upload
-	file, err := c.FormFile("config")
+	data, err := readFormFile(c, "config")
 	if err != nil {
 		return err
 	}
+	if data == nil {
+		return c.JSON(http.StatusBadRequest, "config is required")
+	}
 
-	src, err := file.Open()
+	// Reject invalid scenarios before they're persisted
+	config, errs := parser.ValidateYAML(data)
+	if len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, ValidationResult{Errors: errs})
+	}
+
+	// Parse the Config
+	updatedScenario, err := parser.ParseYAMLConfigToScenario(config)
 	if err != nil {
 		return err
 	}
-	defer src.Close()
 
-	// Read and parse YAML file
-	data, err := io.ReadAll(src)
+	// Scenarios yet to be created land within the team they were authorized against
+	updatedScenario.TeamID = authorizedTeam(c)
+
+	// Persist the updated scenario
+	scenarioID := c.Param("id")
+	newScenario, err := client.DB.UpdateScenario(c.Request().Context(), scenarioID, updatedScenario)
 	if err != nil {
 		return err
 	}
 
-	var config config.Config
-	err = yaml.Unmarshal(data, &config)
+	// Record the changes across versions in the audit log
+	previous := models.Scenario{}
+	if newScenario.Version > 1 {
+		previous, err = client.DB.GetScenarioByIDByVersion(c.Request().Context(), newScenario.ID, newScenario.Version-1)
+		if err != nil {
+			return err
+		}
+	}
+	diff, err := audit.Diff(previous, newScenario)
 	if err != nil {
 		return err
 	}
-
-	// Parse the Config
-	updatedScenario, err := parser.ParseYAMLConfigToScenario(&config)
+	err = client.audit(c, models.AuditScenarioUpdate, models.AuditTargetScenario, newScenario.ID, diff)
 	if err != nil {
 		return err
 	}
 
-	// Persist the updated scenario
-	_, err = client.DB.UpdateScenario(c.Request().Context(), updatedScenario.ID, updatedScenario)
+	return c.JSON(http.StatusOK, newScenario)
+}
+
+// Validates a scenario without persisting it, reporting every invalid field
+func (client *APIServer) ValidateScenario(c echo.Context) error {
+	data, err := readFormFile(c, "config")
 	if err != nil {
 		return err
 	}
+	if data == nil {
+		return c.JSON(http.StatusBadRequest, "config is required")
+	}
+
+	if _, errs := parser.ValidateYAML(data); len(errs) > 0 {
+		return c.JSON(http.StatusUnprocessableEntity, ValidationResult{Errors: errs})
+	}
+	return c.JSON(http.StatusOK, ValidationResult{Valid: true})
+}
+
+// Previews the pods a session would act upon across the clusters it targets, without acting upon any
+func (client *APIServer) PlanScenario(c echo.Context) error {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid version")
+	}
+
+	// Fetch the scenario version
+	scenario, err := client.DB.GetScenarioByIDByVersion(c.Request().Context(), c.Param("id"), version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	// Resolve the clusters a session would target
+	targets, err := client.resolveClusterTargets(c, scenario)
+	if err != nil {
+		return clusterTargetError(c, err)
+	}
+
+	tc, rc, err := parser.ParseDBScenario(scenario)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	if err := client.resolveSeed(c, scenario, rc); err != nil {
+		return seedError(c, err)
+	}
+
+	// Plan onto every cluster, clusters which can't be reached don't prevent planning onto the others
+	plans := make([]ClusterPlan, len(targets))
+	for i, target := range targets {
+		plans[i].Cluster = target.Name
+
+		executor, err := k8x.CreateExecutor(target.Config, tc, rc, client.Logger.With(zap.String("cluster", target.Name)))
+		if err != nil {
+			plans[i].Error = err.Error()
+			continue
+		}
+
+		plans[i].Plan, err = executor.Plan(c.Request().Context())
+		executor.Close()
+		if err != nil {
+			plans[i].Error = err.Error()
+		}
+	}
 
-	return c.NoContent(http.StatusOK)
+	return c.JSON(http.StatusOK, plans)
 }
@@ -3,42 +3,22 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/models"
 	"github.com/wizenheimer/cascade/internal/parser"
+	"github.com/wizenheimer/cascade/service/database"
 	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"github.com/wizenheimer/cascade/service/runner"
 	"go.uber.org/zap"
 )
 
 func (client *APIServer) CreateSession(c echo.Context) error {
-	// Set Headers
-	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
-	c.Response().Header().Set("Cache-Control", "no-cache")
-	c.Response().Header().Set("Connection", "keep-alive")
-	c.Response().WriteHeader(http.StatusOK)
-
-	// Inject Loggers with Hook
-	loggerWithChan := log.LoggerPool.Get().(*log.LoggerWithChannel)
-	defer func() {
-		// Clear the channel before putting the logger back in the pool
-		for len(loggerWithChan.LogChan) > 0 {
-			<-loggerWithChan.LogChan
-		}
-		log.LoggerPool.Put(loggerWithChan)
-	}()
-
-	logger := loggerWithChan.Logger
-	logChan := loggerWithChan.LogChan
-
-	// Create a new context that will be cancelled when the request is done
-	ctx, cancel := context.WithCancel(c.Request().Context())
-	defer cancel()
-
 	scenarioStr := c.Param("scenario")
 	scenarioVersionStr := c.Param("version")
 	version, err := strconv.Atoi(scenarioVersionStr)
@@ -52,113 +32,339 @@ func (client *APIServer) CreateSession(c echo.Context) error {
 		return c.JSON(http.StatusUnprocessableEntity, err)
 	}
 
-	// Trigger a session
-	session, err := client.DB.CreateSession(c.Request().Context(), scenarioStr, version)
+	// Resolve the clusters the session targets
+	targets, err := client.resolveClusterTargets(c, scenario)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err)
+		return clusterTargetError(c, err)
 	}
 
-	// Parse cluster configs
-	cc, err := parser.ParseClusterConfigFromContext(c)
+	// Parse the target, runtime and fan-out config
+	tc, rc, err := parser.ParseDBScenario(scenario)
 	if err != nil {
-		// Parse the log
-		data, err := log.ParseLog("error", err.Error())
-		if err != nil {
-			return err
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+	fc, err := parser.ParseFanOutConfigFromContext(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := client.resolveSeed(c, scenario, rc); err != nil {
+		return seedError(c, err)
+	}
+	detach := false
+	if detachStr := c.FormValue("detach"); detachStr != "" {
+		if detach, err = strconv.ParseBool(detachStr); err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid detach")
 		}
+	}
 
-		// Send response back to client
-		_, err = c.Response().Write(data)
-		if err != nil {
-			return err
+	// Sessions targeting a single registered cluster reference it directly
+	clusterID := ""
+	if len(targets) == 1 {
+		clusterID = targets[0].ID
+	}
+
+	// Trigger a session
+	session, err := client.DB.CreateSession(c.Request().Context(), scenarioStr, version, actor(c), clusterID, rc.Seed)
+	if err != nil {
+		var inactive *database.InactiveError
+		if errors.As(err, &inactive) {
+			return c.JSON(http.StatusConflict, err.Error())
 		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	sessionID := strconv.Itoa(session.ID)
 
-		c.Response().Flush()
-		return nil
+	// Prepare the session across every cluster
+	runnerTargets := make([]runner.Target, len(targets))
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		runnerTargets[i] = runner.Target{Name: target.Name, Config: target.Config}
+		names[i] = target.Name
 	}
 
-	// Parse the target and runtime config
-	tc, rc, err := parser.ParseDBScenario(scenario)
+	from := origin(c)
+	hooks := runner.Hooks{
+		// Record the per cluster status of the session
+		OnUpdate: func(ctx context.Context, run *runner.Session, report k8x.ClusterReport) {
+			row := sessionClusterFromReport(session.ID, report)
+			if err := client.DB.UpdateSessionCluster(ctx, &row); err != nil {
+				run.Logger.Error("failed to record cluster status", zap.String("cluster", report.Cluster), zap.Error(err))
+			}
+		},
+		OnEnd: func(ctx context.Context, run *runner.Session, report *k8x.FanOutReport) {
+			client.endSession(ctx, from, session, report.Status == k8x.ClusterFailed)
+		},
+	}
+
+	// Broadcast logs onto the clients attaching to the session, and stream them back to the client unless detached
+	live := newLiveSession()
+	sinks := []runner.Sink{live}
+	if !detach {
+		sinks = append(sinks, runner.SinkFunc(func(entry log.LogEntry) error {
+			return writeLogEntry(c, entry)
+		}))
+	}
+
+	run, err := runner.New(runner.Config{
+		ID:       sessionID,
+		Scenario: scenarioStr,
+		Targets:  runnerTargets,
+		Target:   tc,
+		Runtime:  rc,
+		FanOut:   fc,
+		Fields:   []zap.Field{zap.Int("version", version)},
+	}, client.Logger, hooks, sinks...)
 	if err != nil {
-		// Parse the log
-		data, err := log.ParseLog("error", err.Error())
-		if err != nil {
-			return err
+		client.DB.TerminateSession(context.WithoutCancel(c.Request().Context()), sessionID)
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	// Sessions failing to start never run, their clusters are released right away
+	abort := func(err error) error {
+		run.Release()
+		client.DB.TerminateSession(context.WithoutCancel(c.Request().Context()), sessionID)
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	// Record the per cluster status of the session
+	if _, err := client.DB.CreateSessionClusters(c.Request().Context(), session.ID, fc.Strategy.String(), names); err != nil {
+		return abort(err)
+	}
+
+	// Record the session in the audit log
+	if err = client.audit(c, models.AuditSessionStart, models.AuditTargetSession, sessionID, ""); err != nil {
+		return abort(err)
+	}
+
+	// Claim the session, the replica heartbeats it until it ends
+	if _, err := client.DB.StartSession(c.Request().Context(), sessionID, client.Replica); err != nil {
+		return abort(err)
+	}
+	live.run = run
+	client.sessions.add(sessionID, live)
+
+	// Detached sessions run until every cluster is done or they are stopped, clients attach to follow their logs
+	if detach {
+		ctx := context.WithoutCancel(c.Request().Context())
+		go client.runSession(ctx, sessionID, live)
+		return c.JSON(http.StatusAccepted, session)
+	}
+
+	// Set Headers
+	setEventStreamHeaders(c)
+
+	// Run until every cluster is done, or the client disconnects and the clusters wind down
+	client.runSession(c.Request().Context(), sessionID, live)
+	if c.Request().Context().Err() != nil {
+		client.Logger.Info("Client disconnected, stopped log stream", log.Session(sessionID), log.Event(log.EventSessionEnded))
+	}
+	return nil
+}
+
+// Runs the session until it ends, clients attached to it are detached then
+func (client *APIServer) runSession(ctx context.Context, sessionID string, live *liveSession) {
+	defer client.sessions.remove(sessionID)
+	defer live.close()
+	live.run.Run(ctx)
+}
+
+// Lists the sessions of the scenario passed as a query param, of every version unless one is passed
+func (client *APIServer) ListSessions(c echo.Context) error {
+	version := 0
+	if versionStr := c.QueryParam("version"); versionStr != "" {
+		var err error
+		if version, err = strconv.Atoi(versionStr); err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid version")
 		}
+	}
 
-		// Send response back to client
-		_, err = c.Response().Write(data)
-		if err != nil {
-			return err
+	sessions, err := client.DB.ListSessionByScenarioID(c.Request().Context(), c.QueryParam("scenario"), version)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// Streams the logs of a running session via SSE, starting with its recent entries.
+// Detaching leaves the session running
+func (client *APIServer) AttachSession(c echo.Context) error {
+	live, err := client.liveSession(c)
+	if err != nil {
+		return err
+	}
+
+	backlog, entries, cancel := live.subscribe()
+	defer cancel()
+
+	setEventStreamHeaders(c)
+	for _, entry := range backlog {
+		if err := writeLogEntry(c, entry); err != nil {
+			return nil
 		}
+	}
+	for {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			if err := writeLogEntry(c, entry); err != nil {
+				return nil
+			}
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
 
-		c.Response().Flush()
-		return nil
+// Stops a running session, its clusters wind down their current round
+func (client *APIServer) StopSession(c echo.Context) error {
+	live, err := client.liveSession(c)
+	if err != nil {
+		return err
+	}
+
+	live.run.Cancel()
+	if err := client.audit(c, models.AuditKillSwitch, models.AuditTargetSession, c.Param("id"), ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// Returns the session passed as the :id path param if it runs on the replica,
+// the error tells why it can't be reached otherwise
+func (client *APIServer) liveSession(c echo.Context) (*liveSession, error) {
+	if live, ok := client.sessions.get(c.Param("id")); ok {
+		return live, nil
 	}
 
-	// Create executor
-	executor, err := k8x.CreateExecutor(cc, tc, rc, logger)
+	session, err := client.DB.GetSessionByID(c.Request().Context(), c.Param("id"))
 	if err != nil {
-		// Parse the log
-		data, err := log.ParseLog("error", err.Error())
+		return nil, err
+	}
+	switch session.Status {
+	case "queued", "running":
+		// Sessions are only reachable through the replica running them
+		return nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("session %d runs on replica %s", session.ID, session.Owner))
+	default:
+		return nil, echo.NewHTTPError(http.StatusGone, fmt.Sprintf("session %d has ended", session.ID))
+	}
+}
+
+// Overrides the seed of the scenario with the seed form param, or with the seed of the session passed as the replay form param.
+// Replays select the same victims as the replayed session, as long as the candidates remain the same
+func (client *APIServer) resolveSeed(c echo.Context, scenario models.Scenario, rc *k8x.RuntimeConfig) error {
+	if replay := c.FormValue("replay"); replay != "" {
+		session, err := client.DB.GetSessionByID(c.Request().Context(), replay)
 		if err != nil {
 			return err
 		}
+		if session.ScenarioID != scenario.ID {
+			return fmt.Errorf("session %s didn't run scenario %s", replay, scenario.ID)
+		}
+		rc.Seed = session.Seed
+		return nil
+	}
 
-		// Send response back to client
-		_, err = c.Response().Write(data)
+	if seedStr := c.FormValue("seed"); seedStr != "" {
+		seed, err := parser.ParseSeed(seedStr)
 		if err != nil {
 			return err
 		}
-
-		c.Response().Flush()
-		return nil
+		rc.Seed = seed
 	}
+	return nil
+}
 
-	// Create ticker
-	ticker := time.NewTicker(rc.Interval)
-	defer ticker.Stop()
+// Maps errors resolving the seed onto their status codes
+func seedError(c echo.Context, err error) error {
+	var notFound *database.NotFoundError
+	if errors.As(err, &notFound) {
+		return c.JSON(http.StatusNotFound, err.Error())
+	}
+	return c.JSON(http.StatusUnprocessableEntity, err.Error())
+}
 
-	// Start processing in a goroutine
-	go func(executor *k8x.Executor, sessionID string, ctx context.Context, next <-chan time.Time) {
-		for {
-			executor.Logger.Info("Chaos Session Triggered", zap.Any("Session", sessionID))
-			executor.Logger.Info("Chaos Scenario", zap.Any("Scenario", scenario))
+func (client *APIServer) GetSessionReport(c echo.Context) error {
+	// Combined report of the session along with the status of every cluster it targeted
+	session, err := client.DB.GetSessionByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
 
-			// Trigger Execution
-			if err = executor.Execute(ctx); err != nil {
-				executor.Logger.Error(err.Error())
-			}
+	rows, err := client.DB.ListSessionClusters(c.Request().Context(), session.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
 
-			select {
-			case <-next:
-				// trigger next session
-			case <-ctx.Done():
-				// skip subsequent execution
-				return
-			}
+	reports := make([]k8x.ClusterReport, len(rows))
+	for i, row := range rows {
+		reports[i] = k8x.ClusterReport{
+			Cluster:   row.Cluster,
+			Status:    row.Status,
+			Rounds:    row.Rounds,
+			Victims:   row.Victims,
+			Failures:  row.Failures,
+			Error:     row.Error,
+			StartTime: row.StartTime,
+			EndTime:   row.EndTime,
 		}
-	}(executor, string(session.ID), ctx, ticker.C)
+	}
 
-	// Stream logs back to the client
-	for {
-		select {
-		case logEntry := <-logChan:
-			// Serialize logEntry to JSON
-			data, err := json.Marshal(logEntry)
-			if err != nil {
-				continue
-			}
-			// Send log entry to client
-			_, err = c.Response().Write([]byte(fmt.Sprintf("data: %s\n\n", data)))
-			if err != nil {
-				return err
-			}
-			c.Response().Flush()
-		case <-ctx.Done():
-			logger.Info("Client disconnected, stopping log stream")
-			client.DB.GracefullyEndSession(c.Request().Context(), string(session.ID))
-			return nil
-		}
+	return c.JSON(http.StatusOK, SessionReport{
+		Session:      *session,
+		FanOutReport: k8x.Summarize(session.FanOut, reports),
+	})
+}
+
+// Marks the session as ended, and records it in the audit log
+func (client *APIServer) endSession(ctx context.Context, from requestOrigin, session *models.Session, failed bool) {
+	sessionID := strconv.Itoa(session.ID)
+	if failed {
+		client.DB.TerminateSession(ctx, sessionID)
+	} else {
+		client.DB.GracefullyEndSession(ctx, sessionID)
+	}
+	if err := client.auditFrom(ctx, from, models.AuditSessionEnd, models.AuditTargetSession, sessionID, ""); err != nil {
+		client.Logger.Error("failed to record session end", zap.Error(err))
+	}
+}
+
+// Prepares the response for streaming server sent events
+func setEventStreamHeaders(c echo.Context) {
+	c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+}
+
+// Sends a log entry to the client as a server sent event
+func writeLogEntry(c echo.Context, logEntry log.LogEntry) error {
+	// Serialize logEntry to JSON
+	data, err := json.Marshal(logEntry)
+	if err != nil {
+		return nil
+	}
+	// Send log entry to client
+	_, err = c.Response().Write([]byte(fmt.Sprintf("data: %s\n\n", data)))
+	if err != nil {
+		return err
+	}
+	c.Response().Flush()
+	return nil
+}
+
+// Converts the outcome of a cluster onto its database row
+func sessionClusterFromReport(sessionID int, report k8x.ClusterReport) models.SessionCluster {
+	return models.SessionCluster{
+		SessionID: sessionID,
+		Cluster:   report.Cluster,
+		Status:    report.Status,
+		Rounds:    report.Rounds,
+		Victims:   report.Victims,
+		Failures:  report.Failures,
+		Error:     report.Error,
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
 	}
 }
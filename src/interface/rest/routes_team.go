@@ -1,32 +1,240 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/audit"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/service/database"
 )
 
+// Upper bound on the length of team names, as per cascade.teams.team_name
+const maxTeamNameLength = 100
+
 func (client *APIServer) CreateTeam(c echo.Context) error {
-	// Create a Team
-	return c.NoContent(http.StatusOK) // TODO
+	// Create a Team, the caller becomes its owner
+	var req TeamRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxTeamNameLength {
+		return c.JSON(http.StatusBadRequest, "name must be between 1 and 100 characters")
+	}
+
+	team, err := client.DB.CreateTeam(c.Request().Context(), name, req.Description, caller(c))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	diff, err := audit.Diff(models.Team{}, team)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditTeamCreate, models.AuditTargetTeam, team.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusCreated, team)
 }
 
 func (client *APIServer) DeleteTeam(c echo.Context) error {
-	// TODO: Delete a Team
-	return c.NoContent(http.StatusOK) // TODO
+	// Deactivate a Team, its scenarios are no longer allowed to run
+	team, err := client.DB.DeactivateTeam(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	if err := client.audit(c, models.AuditTeamDelete, models.AuditTargetTeam, team.ID, ""); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
 
 func (client *APIServer) ListUsers(c echo.Context) error {
-	// TODO: List Team Users
-	return c.NoContent(http.StatusOK) // TODO
+	// List Team Users along with their Membership
+	if _, err := client.DB.GetTeamByID(c.Request().Context(), c.Param("id")); err != nil {
+		return teamError(c, err)
+	}
+
+	members, err := client.DB.ListMembersByTeam(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, members)
 }
 
 func (client *APIServer) ManageTeam(c echo.Context) error {
-	// TODO: Implement Team Attribute Management
-	return c.NoContent(http.StatusOK) // TODO
+	// Update Team Attributes, teams can be reactivated by setting active
+	var req TeamRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if len(strings.TrimSpace(req.Name)) > maxTeamNameLength {
+		return c.JSON(http.StatusBadRequest, "name must be between 1 and 100 characters")
+	}
+
+	ctx := c.Request().Context()
+	teamID := c.Param("id")
+
+	previous, err := client.DB.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	team, err := client.DB.UpdateTeambyTeamID(ctx, teamID, &models.Team{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+	})
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	if req.Active != nil && *req.Active != team.IsActive {
+		if *req.Active {
+			team, err = client.DB.ActivateTeam(ctx, teamID)
+		} else {
+			team, err = client.DB.DeactivateTeam(ctx, teamID)
+		}
+		if err != nil {
+			return teamError(c, err)
+		}
+	}
+
+	diff, err := audit.Diff(previous, team)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditTeamUpdate, models.AuditTargetTeam, team.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, team)
 }
 
 func (client *APIServer) ManageUsers(c echo.Context) error {
-	// TODO: Implement User Management
-	return c.NoContent(http.StatusOK) // TODO
+	// Add a User onto the Team, or change their Membership
+	var req MembershipRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if req.Role == "" {
+		req.Role = models.MembershipMember
+	}
+	if req.Role != models.MembershipOwner && req.Role != models.MembershipMember {
+		return c.JSON(http.StatusBadRequest, "role must be one of owner, member")
+	}
+
+	ctx := c.Request().Context()
+	team, err := client.DB.GetTeamByID(ctx, c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	user, err := client.DB.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	// Demoting the last owner would leave the team unmanageable
+	if req.Role != models.MembershipOwner {
+		if err := client.ensureAnotherOwner(c, team.ID, user.ID); err != nil {
+			return teamError(c, err)
+		}
+	}
+
+	if _, err := client.DB.AddUserToTeam(ctx, user, team, req.Role); err != nil {
+		return teamError(c, err)
+	}
+
+	diff, err := audit.Diff(MembershipRequest{}, MembershipRequest{Email: user.Email, Role: req.Role})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditTeamAddUser, models.AuditTargetTeam, team.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, models.TeamMember{User: *user, Membership: req.Role})
+}
+
+func (client *APIServer) RemoveUser(c echo.Context) error {
+	// Remove a User from the Team
+	ctx := c.Request().Context()
+	team, err := client.DB.GetTeamByID(ctx, c.Param("id"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	user, err := client.DB.GetUserByEmail(ctx, c.Param("email"))
+	if err != nil {
+		return teamError(c, err)
+	}
+
+	if err := client.ensureAnotherOwner(c, team.ID, user.ID); err != nil {
+		return teamError(c, err)
+	}
+
+	if _, err := client.DB.RemoveUserFromTeam(ctx, user, team); err != nil {
+		return teamError(c, err)
+	}
+
+	diff, err := audit.Diff(MembershipRequest{Email: user.Email}, MembershipRequest{})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditTeamRemoveUser, models.AuditTargetTeam, team.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+var errLastOwner = errors.New("team must keep at least one owner")
+
+// Ensures the team keeps at least one owner besides the given user
+func (client *APIServer) ensureAnotherOwner(c echo.Context, teamID string, userID string) error {
+	members, err := client.DB.ListMembersByTeam(c.Request().Context(), teamID)
+	if err != nil {
+		return err
+	}
+
+	isOwner, hasOtherOwner := false, false
+	for _, member := range members {
+		if member.Membership != models.MembershipOwner {
+			continue
+		}
+		if member.ID == userID {
+			isOwner = true
+		} else {
+			hasOtherOwner = true
+		}
+	}
+
+	if isOwner && !hasOtherOwner {
+		return errLastOwner
+	}
+	return nil
+}
+
+// Renders errors raised by team management
+func teamError(c echo.Context, err error) error {
+	var notFound *database.NotFoundError
+	if errors.As(err, &notFound) {
+		return c.JSON(http.StatusNotFound, err.Error())
+	}
+
+	var conflict *database.ConflictError
+	if errors.As(err, &conflict) || errors.Is(err, errLastOwner) {
+		return c.JSON(http.StatusConflict, err.Error())
+	}
+
+	return c.JSON(http.StatusInternalServerError, err)
 }
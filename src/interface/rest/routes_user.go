@@ -1,26 +1,232 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
+	"net/mail"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/wizenheimer/cascade/internal/audit"
+	"github.com/wizenheimer/cascade/internal/auth"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/rbac"
+	"github.com/wizenheimer/cascade/service/database"
 )
 
 func (client *APIServer) SignUp(c echo.Context) error {
-	// TODO: Create a Team
-	return c.NoContent(http.StatusOK) // TODO
+	// Sign Up a User using Email and Password
+	var req CredentialsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid email")
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		return c.JSON(http.StatusBadRequest, "password is too short")
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	user, err := client.DB.CreateUser(c.Request().Context(), &models.User{
+		Email:    email,
+		Password: hash,
+		Role:     string(rbac.User),
+		IsActive: true,
+	})
+	if err != nil {
+		var conflict *database.ConflictError
+		if errors.As(err, &conflict) {
+			return c.JSON(http.StatusConflict, "user already exists")
+		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusCreated, user)
 }
 
 func (client *APIServer) Login(c echo.Context) error {
-	// TODO: Create a Team
-	return c.NoContent(http.StatusOK) // TODO
+	// Login a User identified by their Email, hands out a Session Token
+	var req CredentialsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	user, err := client.DB.GetUserByEmail(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, auth.ErrInvalidCredentials.Error())
+	}
+	if !user.IsActive {
+		return c.JSON(http.StatusUnauthorized, auth.ErrInvalidCredentials.Error())
+	}
+	if err := auth.CheckPassword(user.Password, req.Password); err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	token, claims, err := client.Tokens.Issue(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, TokenResponse{
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
 }
 
 func (client *APIServer) Logout(c echo.Context) error {
-	// TODO: Create a Team
-	return c.NoContent(http.StatusOK) // TODO
+	// Logout a User by revoking the Session Token used for the request
+	if caller(c).Email != c.Param("id") {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	if err := client.revokeSessionToken(c); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusOK)
 }
 
 func (client *APIServer) Churn(c echo.Context) error {
-	return c.NoContent(http.StatusOK) // TODO
+	// Deactivate a User, their credentials stop working right away
+	if caller(c).Email != c.Param("id") {
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	if _, err := client.DB.DeactivateUser(c.Request().Context(), c.Param("id")); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	if err := client.revokeSessionToken(c); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (client *APIServer) UpdateRole(c echo.Context) error {
+	// Change the Role of a User, identified by their Email
+	var req RoleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if !rbac.Valid(req.Role) {
+		return c.JSON(http.StatusBadRequest, "role must be one of user, manager, admin")
+	}
+
+	// Admins demoting themselves could leave nobody to manage roles
+	if caller(c).Email == c.Param("email") {
+		return c.JSON(http.StatusForbidden, "can't change your own role")
+	}
+
+	ctx := c.Request().Context()
+	user, err := client.DB.GetUserByEmail(ctx, c.Param("email"))
+	if err != nil {
+		var notFound *database.NotFoundError
+		if errors.As(err, &notFound) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	previous := RoleRequest{Role: user.Role}
+	user, err = client.DB.UpdateUser(ctx, user.Email, &models.User{Role: req.Role})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	diff, err := audit.Diff(previous, req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	if err := client.audit(c, models.AuditUserRole, models.AuditTargetUser, user.ID, diff); err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+func (client *APIServer) CreateAPIKey(c echo.Context) error {
+	// Issue an API Key for the caller, optionally scoped to one of their teams
+	var req APIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, "name is required")
+	}
+
+	user := caller(c)
+	key := &models.APIKey{
+		Name:   req.Name,
+		UserID: user.ID,
+	}
+
+	if req.Team != "" {
+		_, err := client.DB.GetMembership(c.Request().Context(), req.Team, user.ID)
+		if err != nil {
+			var notFound *database.NotFoundError
+			if errors.As(err, &notFound) {
+				return c.NoContent(http.StatusForbidden)
+			}
+			return c.JSON(http.StatusInternalServerError, err)
+		}
+		key.TeamID = &req.Team
+	}
+
+	raw, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+	key.Prefix = prefix
+	key.Hash = hash
+
+	key, err = client.DB.CreateAPIKey(c.Request().Context(), key)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusCreated, APIKeyResponse{
+		Key:    raw,
+		APIKey: *key,
+	})
+}
+
+func (client *APIServer) ListAPIKeys(c echo.Context) error {
+	// List out API Keys issued by the caller
+	keys, err := client.DB.ListAPIKeysByUser(c.Request().Context(), actor(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+func (client *APIServer) RevokeAPIKey(c echo.Context) error {
+	// Revoke an API Key issued by the caller
+	key, err := client.DB.RevokeAPIKey(c.Request().Context(), c.Param("key"), actor(c))
+	if err != nil {
+		var notFound *database.NotFoundError
+		if errors.As(err, &notFound) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.JSON(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+// Revokes the session token used for the request, if any
+func (client *APIServer) revokeSessionToken(c echo.Context) error {
+	claims := claims(c)
+	if claims == nil {
+		return nil
+	}
+	return client.DB.RevokeToken(c.Request().Context(), claims.ID, claims.ExpiresAt.Time)
 }
@@ -0,0 +1,72 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/models"
+	"go.uber.org/zap"
+)
+
+// Heartbeats the sessions owned by the replica, until the context is cancelled
+func (api *APIServer) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(api.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := api.DB.HeartbeatSessions(ctx, api.Replica); err != nil {
+				api.Logger.Error("failed to heartbeat sessions", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Runs scheduler duties while the replica leads, until the context is cancelled
+func (api *APIServer) schedule(ctx context.Context) {
+	ticker := time.NewTicker(api.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		api.reapStaleSessions(ctx)
+
+		select {
+		case <-ticker.C:
+			// run duties again
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Fails sessions whose owner stopped heartbeating, their clients went down along with the owner
+func (api *APIServer) reapStaleSessions(ctx context.Context) {
+	sessions, err := api.DB.FailStaleSessions(ctx, time.Now().Add(-api.SessionTimeout))
+	if err != nil {
+		api.Logger.Error("failed to reap stale sessions", zap.Error(err))
+		return
+	}
+
+	for _, session := range sessions {
+		sessionID := strconv.Itoa(session.ID)
+		api.Logger.Warn("Session owner stopped heartbeating, marking session as failed",
+			zap.String("session", sessionID),
+			zap.String("owner", session.Owner),
+			zap.Time("heartbeat_at", session.HeartbeatAt),
+		)
+
+		_, err := api.DB.CreateAuditEntry(ctx, &models.AuditEntry{
+			Action:     models.AuditSessionEnd,
+			TargetType: models.AuditTargetSession,
+			TargetID:   sessionID,
+			Diff:       "owner " + session.Owner + " stopped heartbeating",
+		})
+		if err != nil {
+			api.Logger.Error("failed to record session end", zap.String("session", sessionID), zap.Error(err))
+		}
+	}
+}
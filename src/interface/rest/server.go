@@ -2,32 +2,89 @@ package rest
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/wizenheimer/cascade/internal/auth"
 	"github.com/wizenheimer/cascade/internal/config"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/rbac"
+	"github.com/wizenheimer/cascade/internal/secret"
 	"github.com/wizenheimer/cascade/service/database"
+	"github.com/wizenheimer/cascade/service/election"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Initialize API Instance
-func NewAPIServer(logger *zap.Logger) APIServer {
-	db, err := initalizeDBClient(logger)
+func NewAPIServer(logger *zap.Logger, opts ServerOptions) APIServer {
+	db, err := initalizeDBClient(logger, opts.DSN)
 	if err != nil {
 		logger.Fatal("failed to initialize Database Client", zap.Any("error", err))
 	}
 
+	if err := migrateDB(logger, db); err != nil {
+		logger.Fatal("failed to migrate Database", zap.Any("error", err))
+	}
+
+	if err := seedAdmin(logger, db); err != nil {
+		logger.Fatal("failed to seed Admin", zap.Any("error", err))
+	}
+
+	tokens, err := initializeTokenIssuer(logger)
+	if err != nil {
+		logger.Fatal("failed to initialize Token Issuer", zap.Any("error", err))
+	}
+
+	sealer, err := initializeSealer(logger)
+	if err != nil {
+		logger.Fatal("failed to initialize Sealer", zap.Any("error", err))
+	}
+
+	replica := config.GetEnv("REPLICA_ID", election.Identity())
+	elector, err := initializeElector(logger, db, replica)
+	if err != nil {
+		logger.Fatal("failed to initialize Leader Election", zap.Any("error", err))
+	}
+
+	heartbeat, err := time.ParseDuration(config.GetEnv("HEARTBEAT_INTERVAL", config.HEARTBEAT_INTERVAL))
+	if err != nil {
+		logger.Fatal("failed to parse HEARTBEAT_INTERVAL", zap.Any("error", err))
+	}
+
+	sessionTimeout, err := time.ParseDuration(config.GetEnv("SESSION_TIMEOUT", config.SESSION_TIMEOUT))
+	if err != nil {
+		logger.Fatal("failed to parse SESSION_TIMEOUT", zap.Any("error", err))
+	}
+
 	api := APIServer{
 		// Inject Logger
 		Logger: logger,
 		// Inject Database Client
 		DB: db,
+		// Inject Token Issuer
+		Tokens: tokens,
+		// Inject Sealer for Cluster Credentials
+		Sealer: sealer,
+		// Inject Replica Identity along with Leader Election
+		Replica:        replica,
+		Elector:        elector,
+		Heartbeat:      heartbeat,
+		SessionTimeout: sessionTimeout,
+		sessions:       newLiveSessions(),
 	}
 
 	// Create Echo
@@ -37,8 +94,12 @@ func NewAPIServer(logger *zap.Logger) APIServer {
 	e.Use(middleware.RequestID())
 
 	// Create a server
+	addr := opts.Addr
+	if addr == "" {
+		addr = config.GetEnv("SERVER_ADDR", config.SERVER_PORT)
+	}
 	s := http.Server{
-		Addr:    config.SERVER_PORT,
+		Addr:    addr,
 		Handler: e,
 	}
 
@@ -52,42 +113,187 @@ func NewAPIServer(logger *zap.Logger) APIServer {
 	return api
 }
 
-// Initalize Database Client using environment variables
-func initalizeDBClient(logger *zap.Logger) (database.DatabaseClient, error) {
-	// Load .env file incase of local development
+// Initalize Database Client from the data source name, falling back onto environment variables
+func initalizeDBClient(logger *zap.Logger, dsn string) (database.DatabaseClient, error) {
+	// Load .env file incase of local development, variables set already take precedence
 	if os.Getenv("ENVIRONMENT") != "docker" {
-		err := godotenv.Load("../.env")
+		if err := godotenv.Load("../.env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("couldn't load .env file", zap.Any("error", err))
+		}
+	}
+
+	if dsn == "" {
+		dsn = os.Getenv("DATABASE_URL")
+	}
+	if dsn != "" {
+		db, err := database.NewDatabaseClientFromDSN(dsn)
 		if err != nil {
-			logger.Fatal("couldn't load .env file", zap.Any("error", err))
+			return nil, err
 		}
+		logger.Info("Connected to Database", zap.Bool("sqlite", strings.HasPrefix(dsn, database.SQLitePrefix)))
+		return db, nil
 	}
+
 	host := os.Getenv("POSTGRES_HOST")
+	if host == "" {
+		return nil, errors.New("no database configured, set DATABASE_URL or the POSTGRES_* variables")
+	}
 	user := os.Getenv("POSTGRES_USER")
 	password := os.Getenv("POSTGRES_PASSWORD")
 	dbname := os.Getenv("POSTGRES_DB")
 	sslMode := os.Getenv("SSL_MODE")
 	port, err := strconv.Atoi(os.Getenv("POSTGRES_PORT"))
 	if err != nil {
-		logger.Fatal("failed to convert port to int", zap.Any("error", err))
+		return nil, fmt.Errorf("failed to convert POSTGRES_PORT to int: %w", err)
 	}
 
 	// Create Database Client
 	db, err := database.NewDatabaseClient(host, user, password, dbname, int32(port), sslMode)
 	if err != nil {
-		logger.Fatal("failed to initialize Database Client", zap.Any("error", err))
-	} else {
-		logger.Info("Connected to Database", zap.Any("host", host), zap.Any("user", user), zap.Any("dbname", dbname), zap.Any("port", port), zap.Any("sslMode", sslMode))
+		return nil, err
 	}
+	logger.Info("Connected to Database", zap.Any("host", host), zap.Any("user", user), zap.Any("dbname", dbname), zap.Any("port", port), zap.Any("sslMode", sslMode))
 
 	return db, nil
 }
 
+// Apply pending schema migrations unless AUTO_MIGRATE is disabled, replicas starting at once apply each of them once
+func migrateDB(logger *zap.Logger, db database.DatabaseClient) error {
+	if auto, err := strconv.ParseBool(config.GetEnv("AUTO_MIGRATE", config.AUTO_MIGRATE)); err != nil || !auto {
+		return err
+	}
+
+	applied, err := db.MigrateUp(context.Background(), 0)
+	for _, m := range applied {
+		logger.Info("Applied Migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+	return err
+}
+
+// Seed the admin identified by ADMIN_EMAIL, signing them up with ADMIN_PASSWORD unless they exist already
+// Every other user signs up with the user role, only admins grant roles beyond it
+func seedAdmin(logger *zap.Logger, db database.DatabaseClient) error {
+	email := os.Getenv("ADMIN_EMAIL")
+	if email == "" {
+		return nil
+	}
+
+	ctx := context.Background()
+	user, err := db.GetUserByEmail(ctx, email)
+	var notFound *database.NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		password := os.Getenv("ADMIN_PASSWORD")
+		if len(password) < auth.MinPasswordLength {
+			return fmt.Errorf("ADMIN_PASSWORD must be at least %d characters long", auth.MinPasswordLength)
+		}
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return err
+		}
+		if _, err := db.CreateUser(ctx, &models.User{
+			Email:    email,
+			Password: hash,
+			Role:     string(rbac.Admin),
+			IsActive: true,
+		}); err != nil {
+			return err
+		}
+		logger.Info("Seeded Admin", zap.String("email", email))
+	case err != nil:
+		return err
+	case user.Role != string(rbac.Admin):
+		if _, err := db.UpdateUser(ctx, email, &models.User{Role: string(rbac.Admin)}); err != nil {
+			return err
+		}
+		logger.Info("Promoted Admin", zap.String("email", email))
+	}
+	return nil
+}
+
+// Initialize Token Issuer using environment variables
+func initializeTokenIssuer(logger *zap.Logger) (*auth.TokenIssuer, error) {
+	ttl, err := time.ParseDuration(config.GetEnv("TOKEN_TTL", config.TOKEN_TTL))
+	if err != nil {
+		return nil, err
+	}
+
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	if len(secret) == 0 {
+		// Tokens won't survive a restart, nor be accepted across replicas
+		logger.Warn("JWT_SECRET is not set, generating an ephemeral secret")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auth.TokenIssuer{
+		Secret: secret,
+		TTL:    ttl,
+	}, nil
+}
+
+// Initialize Sealer for Cluster Credentials using environment variables,
+// the cluster registry stays disabled unless a key is set
+func initializeSealer(logger *zap.Logger) (*secret.Sealer, error) {
+	key := os.Getenv("CLUSTER_SECRET_KEY")
+	if key == "" {
+		logger.Warn("CLUSTER_SECRET_KEY is not set, cluster registry is disabled")
+		return nil, nil
+	}
+
+	return secret.NewSealer(key)
+}
+
+// Initialize Leader Election using environment variables
+func initializeElector(logger *zap.Logger, db database.DatabaseClient, replica string) (election.Elector, error) {
+	leaseDuration, err := time.ParseDuration(config.GetEnv("LEASE_DURATION", config.LEASE_DURATION))
+	if err != nil {
+		return nil, err
+	}
+	retryPeriod := leaseDuration / 3
+
+	logger = logger.With(zap.String("replica", replica))
+	switch strategy := config.GetEnv("LEADER_ELECTION", config.LEADER_ELECTION); strategy {
+	case "none":
+		return election.Standalone{}, nil
+	case "postgres":
+		return election.NewAdvisoryLock(db, retryPeriod, logger), nil
+	case "lease":
+		restConfig, err := k8x.RestConfig(&k8x.ClusterConfig{
+			Origin:     config.GetEnv("ORIGIN", config.ORIGIN),
+			Kubeconfig: os.Getenv("KUBECONFIG"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		client, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		namespace := config.GetEnv("LEASE_NAMESPACE", config.LEASE_NAMESPACE)
+		name := config.GetEnv("LEASE_NAME", config.LEASE_NAME)
+		return election.NewLease(client, namespace, name, replica, leaseDuration, retryPeriod, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown leader election strategy %q, expected none, postgres or lease", strategy)
+	}
+}
+
 // Trigger Serving
 func (api *APIServer) Serve() {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Heartbeat owned sessions, and contend for scheduler duties
+	go api.heartbeat(ctx)
+	go api.Elector.Run(ctx, election.Callbacks{
+		OnStartedLeading: api.schedule,
+	})
+
 	// Start server
+	api.Logger.Info("Serving API", zap.String("addr", api.server.Addr))
 	go func() {
 		if err := api.server.ListenAndServe(); err != http.ErrServerClosed {
 			api.Logger.Fatal(err.Error())
@@ -98,6 +304,9 @@ func (api *APIServer) Serve() {
 	<-ctx.Done()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+
+	// Stop every session and wait for them to wind down, detached sessions would outlive the server otherwise
+	api.sessions.stopAll(ctx)
 	if err := api.server.Shutdown(ctx); err != nil {
 		api.Logger.Error(err.Error())
 	}
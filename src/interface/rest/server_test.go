@@ -0,0 +1,213 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/models"
+	"go.uber.org/zap"
+)
+
+const testScenario = `scenario:
+  id: checkout-pods
+  description: end to end scenario
+target:
+  namespaces: test
+runtime:
+  interval: 1m
+  mode: dry-run
+`
+
+// Client of the API under test, authenticated once a token is set
+type testClient struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+func (tc *testClient) do(method string, path string, contentType string, body io.Reader, want int, out any) {
+	tc.t.Helper()
+
+	req, err := http.NewRequest(method, tc.url+path, body)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if tc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	data, _ := io.ReadAll(res.Body)
+	if res.StatusCode != want {
+		tc.t.Fatalf("%s %s = %d %s, want %d", method, path, res.StatusCode, data, want)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			tc.t.Fatalf("%s %s: %v, body %s", method, path, err, data)
+		}
+	}
+}
+
+func (tc *testClient) form(method string, path string, values url.Values, want int, out any) {
+	tc.t.Helper()
+	tc.do(method, path, "application/x-www-form-urlencoded", strings.NewReader(values.Encode()), want, out)
+}
+
+func (tc *testClient) multipart(method string, path string, values url.Values, file string, data []byte, want int, out any) {
+	tc.t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for key, vals := range values {
+		for _, val := range vals {
+			w.WriteField(key, val)
+		}
+	}
+	part, err := w.CreateFormFile(file, file+".yaml")
+	if err != nil {
+		tc.t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+
+	tc.do(method, path, w.FormDataContentType(), &body, want, out)
+}
+
+func (tc *testClient) login(email string, password string) {
+	tc.t.Helper()
+
+	var res TokenResponse
+	tc.form(http.MethodPost, "/auth/"+email, url.Values{"password": {password}}, http.StatusOK, &res)
+	tc.token = res.Token
+}
+
+// Serves the API off a fresh SQLite database, returning a client logged in as the seeded admin
+func testServer(t *testing.T) *testClient {
+	t.Setenv("LEADER_ELECTION", "none")
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "admin-password")
+	t.Setenv("CLUSTER_SECRET_KEY", "test-secret-key")
+
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "cascade.db")
+	api := NewAPIServer(zap.NewNop(), ServerOptions{DSN: dsn})
+	srv := httptest.NewServer(api.server.Handler)
+	t.Cleanup(srv.Close)
+
+	admin := &testClient{t: t, url: srv.URL}
+	admin.login("admin@example.com", "admin-password")
+	return admin
+}
+
+// Signs a user up, has the admin grant them the role unless it's the default one, then logs them in
+func (tc *testClient) signUp(email string, role string) *testClient {
+	tc.t.Helper()
+
+	user := &testClient{t: tc.t, url: tc.url}
+	user.form(http.MethodPost, "/auth", url.Values{"email": {email}, "password": {email + "-password"}}, http.StatusCreated, nil)
+	if role != "user" {
+		tc.form(http.MethodPatch, "/user/"+email+"/role", url.Values{"role": {role}}, http.StatusOK, nil)
+	}
+	user.login(email, email+"-password")
+	return user
+}
+
+// Stands in for the API Server of a cluster without any pods, watches are held open until the client leaves
+func fakeKubernetes(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Query().Get("watch") == "true":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/pods"):
+			fmt.Fprint(w, `{"kind":"PodList","apiVersion":"v1","metadata":{"resourceVersion":"1"},"items":[]}`)
+		case r.Method == http.MethodPost || r.Method == http.MethodPatch:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+		}
+	}))
+	t.Cleanup(func() {
+		srv.CloseClientConnections()
+		srv.Close()
+	})
+	return srv
+}
+
+// Runs a scenario against a registered cluster on a SQLite backed server, from signing up onto the session's report
+func TestSessionLifecycle(t *testing.T) {
+	admin := testServer(t)
+	kube := fakeKubernetes(t)
+
+	// Sign up, then have the seeded admin grant the manager role
+	user := admin.signUp("dev@example.com", "manager")
+
+	var team models.Team
+	user.form(http.MethodPost, "/team", url.Values{"name": {"checkout"}}, http.StatusCreated, &team)
+
+	var cluster models.Cluster
+	user.form(http.MethodPost, "/cluster", url.Values{
+		"name":   {"staging"},
+		"server": {kube.URL},
+		"team":   {team.ID},
+		"token":  {"secret"},
+	}, http.StatusCreated, &cluster)
+
+	var scenario models.Scenario
+	user.multipart(http.MethodPost, "/scenario", url.Values{"team": {team.ID}}, "config", []byte(testScenario), http.StatusOK, &scenario)
+	if scenario.TeamID != team.ID || scenario.Version != 1 {
+		t.Fatalf("created scenario of team %q at version %d, want team %q at version 1", scenario.TeamID, scenario.Version, team.ID)
+	}
+
+	// Detached sessions are handed back right away, then run until stopped
+	var session models.Session
+	user.form(http.MethodPost, "/session/"+scenario.ID+"/1", url.Values{
+		"cluster": {cluster.ID},
+		"detach":  {"true"},
+	}, http.StatusAccepted, &session)
+
+	sessionPath := fmt.Sprintf("/session/%d", session.ID)
+	var report SessionReport
+	user.do(http.MethodGet, sessionPath+"/report", "", nil, http.StatusOK, &report)
+	if len(report.Clusters) != 1 || report.Clusters[0].Cluster != cluster.ID {
+		t.Fatalf("report covers clusters %+v, want %s alone", report.Clusters, cluster.ID)
+	}
+
+	user.do(http.MethodPost, sessionPath+"/stop", "", nil, http.StatusAccepted, nil)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		user.do(http.MethodGet, sessionPath+"/report", "", nil, http.StatusOK, &report)
+		if status := report.Session.Status; status != "queued" && status != "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session still %s after being stopped", report.Session.Status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Ended sessions can't be stopped twice
+	user.do(http.MethodPost, sessionPath+"/stop", "", nil, http.StatusGone, nil)
+}
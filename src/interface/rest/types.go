@@ -2,13 +2,111 @@ package rest
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/wizenheimer/cascade/internal/auth"
+	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/parser"
+	"github.com/wizenheimer/cascade/internal/secret"
 	"github.com/wizenheimer/cascade/service/database"
+	"github.com/wizenheimer/cascade/service/election"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
 	"go.uber.org/zap"
 )
 
+// Options of the API server, unset options fall back onto the environment
+type ServerOptions struct {
+	// Address the server listens on, such as :8080
+	Addr string
+	// Data source name of the database, overriding DATABASE_URL and the POSTGRES_* variables
+	DSN string
+}
+
 type APIServer struct {
 	server *http.Server
 	Logger *zap.Logger
 	DB     database.DatabaseClient
+	Tokens *auth.TokenIssuer
+	Sealer *secret.Sealer
+	// Identity of the replica, owns the sessions it runs
+	Replica string
+	// Elects the replica running scheduler duties
+	Elector election.Elector
+	// Interval at which the replica heartbeats its sessions
+	Heartbeat time.Duration
+	// Sessions not heartbeated for this long are failed by the leader
+	SessionTimeout time.Duration
+	// Sessions running on the replica, reachable to stop or attach to
+	sessions *liveSessions
+}
+
+// Credentials used for signing up and logging in
+type CredentialsRequest struct {
+	Email    string `json:"email" form:"email"`
+	Password string `json:"password" form:"password"`
+}
+
+// Session token handed out on login
+type TokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Role granted to a user, one of user, manager or admin
+type RoleRequest struct {
+	Role string `json:"role" form:"role"`
+}
+
+// Parameters for issuing an API Key, scoped to a team if set
+type APIKeyRequest struct {
+	Name string `json:"name" form:"name"`
+	Team string `json:"team" form:"team"`
+}
+
+// API Key handed out exactly once, on creation
+type APIKeyResponse struct {
+	Key string `json:"key"`
+	models.APIKey
+}
+
+// Attributes of a team, unset fields are left untouched on updates
+type TeamRequest struct {
+	Name        string `json:"name" form:"name"`
+	Description string `json:"description" form:"description"`
+	Active      *bool  `json:"active" form:"active"`
+}
+
+// Membership of a user within a team
+type MembershipRequest struct {
+	Email string `json:"email" form:"email"`
+	Role  string `json:"role" form:"role"`
+}
+
+// Attributes of a registered cluster, the kubeconfig and ca files are passed as multipart files
+type ClusterRequest struct {
+	Name     string `form:"name"`
+	Server   string `form:"server"`
+	Team     string `form:"team"`
+	Context  string `form:"context"`
+	Token    string `form:"token"`
+	Insecure bool   `form:"insecure"`
+}
+
+// Combined report of a session across the clusters it targeted
+type SessionReport struct {
+	Session models.Session `json:"session"`
+	*k8x.FanOutReport
+}
+
+// Plan of a scenario onto one of the clusters it targets
+type ClusterPlan struct {
+	Cluster string `json:"cluster"`
+	*k8x.Plan
+	Error string `json:"error,omitempty"`
+}
+
+// Outcome of validating a scenario, listing every invalid field
+type ValidationResult struct {
+	Valid  bool                    `json:"valid"`
+	Errors parser.ValidationErrors `json:"errors,omitempty"`
 }
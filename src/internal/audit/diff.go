@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Change records the previous and the current value of a field
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Diff compares two records of the same struct type field by field and
+// returns the changed fields keyed by their json name, serialized as JSON.
+// Nested structs, slices and timestamps are ignored. Returns an empty
+// string incase nothing changed.
+func Diff(before, after interface{}) (string, error) {
+	from := reflect.Indirect(reflect.ValueOf(before))
+	to := reflect.Indirect(reflect.ValueOf(after))
+	if from.Type() != to.Type() || from.Kind() != reflect.Struct {
+		return "", nil
+	}
+
+	changes := map[string]Change{}
+	for i := 0; i < from.NumField(); i++ {
+		field := from.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Map, reflect.Pointer:
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prev, next := from.Field(i).Interface(), to.Field(i).Interface()
+		if prev != next {
+			changes[name] = Change{From: prev, To: next}
+		}
+	}
+
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Prefix which sets API Keys apart from session tokens
+const APIKeyPrefix = "cas_"
+
+// Number of characters of the key kept in the clear, for telling keys apart
+const displayLength = 12
+
+// Generates a new API Key, returns the raw key along with its display prefix and hash
+// The raw key is handed out exactly once and is never persisted
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:displayLength], HashAPIKey(key), nil
+}
+
+// Hashes the API Key for looking it up at rest
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Determines if the credential is an API Key rather than a session token
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Issuer of the tokens minted by cascade
+const tokenIssuer = "cascade"
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Signs and verifies session tokens
+type TokenIssuer struct {
+	// HMAC secret used for signing the tokens
+	Secret []byte
+	// Lifetime of the issued tokens
+	TTL time.Duration
+}
+
+// Issues a signed token for the given user
+func (issuer *TokenIssuer) Issue(userID string) (string, *jwt.RegisteredClaims, error) {
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Issuer:    tokenIssuer,
+		Subject:   userID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(issuer.TTL)),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(issuer.Secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// Verifies the token and returns its claims
+func (issuer *TokenIssuer) Parse(token string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return issuer.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -3,6 +3,25 @@ package config
 // API Server Defaults
 const (
 	SERVER_PORT = ":8080"
+
+	TOKEN_TTL = "24h" // Lifetime of session tokens
+
+	AUTO_MIGRATE = "true" // Apply pending schema migrations on startup
+)
+
+// High Availability Defaults
+const (
+	LEADER_ELECTION = "postgres" // One of none, postgres, lease
+
+	LEASE_NAME = "cascade-server"
+
+	LEASE_NAMESPACE = "default"
+
+	LEASE_DURATION = "15s"
+
+	HEARTBEAT_INTERVAL = "10s" // Interval at which replicas heartbeat their sessions
+
+	SESSION_TIMEOUT = "30s" // Sessions not heartbeated for this long are failed
 )
 
 // Executor Defaults
@@ -11,6 +30,12 @@ const (
 
 	RUNTIME_INTERVAL = "10m"
 
+	INTERVAL_DISTRIBUTION = "fixed" // One of fixed, uniform, exponential
+
+	INTERVAL_JITTER = "0" // Spread of every interval, such as 20% or 0.2
+
+	INITIAL_DELAY = "0s" // Delay before the first tick
+
 	RATIO = "0.5"
 
 	MODE = "dry-run"
@@ -19,9 +44,42 @@ const (
 
 	ORDERING = "oldest"
 
+	ROUNDING = "floor" // One of floor, ceil, round
+
+	CONCURRENCY = "10" // Victims acted upon at once
+
+	RATE_LIMIT = "0" // Actions per second, zero leaves them unlimited
+
+	STAGGER = "0s" // Delay between actions on consecutive victims
+
+	JITTER = "0s" // Random delay added onto the stagger
+
 	ORIGIN = "host"
 )
 
+// Fan-out Defaults
+const (
+	FANOUT_STRATEGY = "parallel" // One of parallel, sequential
+
+	FANOUT_PAUSE = "0s" // Pause between clusters of sequential runs
+
+	FANOUT_ROUNDS = "0" // Rounds per cluster, zero runs until cancelled
+)
+
+// Controller Defaults
+const (
+	CONTROLLER_ORIGIN = "cluster" // Controllers run within the cluster they act upon
+
+	CONTROLLER_WORKERS = "2"
+)
+
+// Tracing Defaults
+const (
+	TRACES_EXPORTER = "none" // One of none, otlp, stdout, file
+
+	TRACES_FILE = "traces.json"
+)
+
 // CLI Defaults
 const (
 	FILE_NAME = "scenario.yaml" // Default file name
@@ -6,6 +6,7 @@ type Config struct {
 	Target   Target   `yaml:"target"`
 	Runtime  Runtime  `yaml:"runtime"`
 	Cluster  Cluster  `yaml:"cluster"`
+	FanOut   FanOut   `yaml:"fanout,omitempty"`
 }
 
 // Scenario represents the chaos engineering scenario
@@ -16,25 +17,54 @@ type Scenario struct {
 
 // Target represents the resources to target for chaos engineering scenarios
 type Target struct {
-	Namespaces        string `yaml:"namespaces"`
-	IncludedPodNames  string `yaml:"includedPodNames"`
-	IncludedNodeNames string `yaml:"includedNodeNames"`
-	ExcludedPodNames  string `yaml:"excludedPodNames"`
+	Namespaces        string `json:"namespaces,omitempty" yaml:"namespaces"`
+	IncludedPodNames  string `json:"includedPodNames,omitempty" yaml:"includedPodNames"`
+	IncludedNodeNames string `json:"includedNodeNames,omitempty" yaml:"includedNodeNames"`
+	ExcludedPodNames  string `json:"excludedPodNames,omitempty" yaml:"excludedPodNames"`
 }
 
 // Runtime represents the runtime arguments for executing the scenario
 type Runtime struct {
-	Interval string `yaml:"interval"`
-	Grace    string `yaml:"grace"`
-	Mode     string `yaml:"mode"`
-	Ordering string `yaml:"ordering"`
-	Ratio    string `yaml:"ratio"`
+	Interval string `json:"interval,omitempty" yaml:"interval"`
+	Grace    string `json:"grace,omitempty" yaml:"grace"`
+	Mode     string `json:"mode,omitempty" yaml:"mode"`
+	Ordering string `json:"ordering,omitempty" yaml:"ordering"`
+	Ratio    string `json:"ratio,omitempty" yaml:"ratio"`
+	Seed     string `json:"seed,omitempty" yaml:"seed,omitempty"`
+	// Absolute number of victims, takes precedence over the ratio
+	Count      string `json:"count,omitempty" yaml:"count,omitempty"`
+	MinVictims string `json:"minVictims,omitempty" yaml:"minVictims,omitempty"`
+	MaxVictims string `json:"maxVictims,omitempty" yaml:"maxVictims,omitempty"`
+	// Rounding applied onto the ratio, one of floor, ceil, round
+	Rounding string `json:"rounding,omitempty" yaml:"rounding,omitempty"`
+	// Victims acted upon at once, and actions started per second
+	Concurrency string `json:"concurrency,omitempty" yaml:"concurrency,omitempty"`
+	RateLimit   string `json:"rateLimit,omitempty" yaml:"rateLimit,omitempty"`
+	// Delay between actions on consecutive victims, extended by a random jitter
+	Stagger string `json:"stagger,omitempty" yaml:"stagger,omitempty"`
+	Jitter  string `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	// Distribution intervals are drawn from, one of fixed, uniform, exponential
+	Distribution string `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	MinInterval  string `json:"minInterval,omitempty" yaml:"minInterval,omitempty"`
+	MaxInterval  string `json:"maxInterval,omitempty" yaml:"maxInterval,omitempty"`
+	// Spread of every interval, such as 20% or 0.2
+	IntervalJitter string `json:"intervalJitter,omitempty" yaml:"intervalJitter,omitempty"`
+	InitialDelay   string `json:"initialDelay,omitempty" yaml:"initialDelay,omitempty"`
 }
 
 // Cluster represents the Kubernetes cluster configuration
 type Cluster struct {
-	Kubeconfig  string `yaml:"kubeconfig"`
-	Master      string `yaml:"master"`
-	Origin      string `yaml:"origin"`
-	Healthcheck string `yaml:"healthcheck"`
+	ID          string   `yaml:"id,omitempty"`
+	Kubeconfig  string   `yaml:"kubeconfig"`
+	Master      string   `yaml:"master"`
+	Contexts    []string `yaml:"contexts,omitempty"`
+	Origin      string   `yaml:"origin"`
+	Healthcheck string   `yaml:"healthcheck"`
+}
+
+// FanOut represents how the scenario is spread across several clusters
+type FanOut struct {
+	Strategy string `json:"strategy,omitempty" yaml:"strategy"`
+	Pause    string `json:"pause,omitempty" yaml:"pause"`
+	Rounds   string `json:"rounds,omitempty" yaml:"rounds"`
 }
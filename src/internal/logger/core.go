@@ -9,37 +9,31 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-// Instantiate a logger
-func CreateLogger() *LoggerWithChannel {
-	logChan := make(chan LogEntry, 100)
-	config := zap.NewProductionConfig()
-	config.OutputPaths = []string{"stdout"}
+// Tees info entries and above of the logger onto the channel, regardless of the level of the logger.
+// Entries are dropped while the channel is full
+func WithChannel(logger *zap.Logger, logChan chan LogEntry) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &channelCore{
+			LevelEnabler: zapcore.InfoLevel,
+			logChan:      logChan,
+		})
+	}))
+}
 
-	hook := func(entry zapcore.Entry) error {
-		logEntry := LogEntry{
-			Timestamp: entry.Time.UnixNano() / 1e6,
-			Level:     entry.Level.String(),
-			Message:   entry.Message,
-		}
-		select {
-		case logChan <- logEntry:
-		default:
-			// Channel full, log dropped
-		}
-		return nil
-	}
+// Attaches the session ID to a log entry
+func Session(id string) zap.Field {
+	return zap.String(SessionKey, id)
+}
 
-	logger, _ := config.Build(zap.Hooks(hook))
-	return &LoggerWithChannel{
-		Logger:  logger,
-		LogChan: logChan,
-	}
+// Attaches the event type to a log entry
+func Event(event string) zap.Field {
+	return zap.String(EventKey, event)
 }
 
 // Manually parse log for streaming it back to client
 func ParseLog(level, msg string) ([]byte, error) {
 	logEntry := LogEntry{
-		Timestamp: time.Now().Unix(),
+		Timestamp: time.Now().UnixMilli(),
 		Level:     level,
 		Message:   msg,
 	}
@@ -53,3 +47,66 @@ func ParseLog(level, msg string) ([]byte, error) {
 
 	return data, nil
 }
+
+// channelCore publishes log entries onto a channel instead of writing them out
+type channelCore struct {
+	zapcore.LevelEnabler
+	// Fields accumulated using With
+	fields  []zapcore.Field
+	logChan chan LogEntry
+}
+
+func (c *channelCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *channelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *channelCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	logEntry := LogEntry{
+		Timestamp: entry.Time.UnixMilli(),
+		Level:     entry.Level.String(),
+		Message:   entry.Message,
+	}
+
+	// Lift reserved keys onto the entry
+	if session, ok := enc.Fields[SessionKey].(string); ok {
+		logEntry.Session = session
+		delete(enc.Fields, SessionKey)
+	}
+	if event, ok := enc.Fields[EventKey].(string); ok {
+		logEntry.Event = event
+		delete(enc.Fields, EventKey)
+	}
+	if len(enc.Fields) > 0 {
+		logEntry.Fields = enc.Fields
+	}
+
+	select {
+	case c.logChan <- logEntry:
+	default:
+		// Channel full, log dropped
+	}
+	return nil
+}
+
+func (c *channelCore) Sync() error {
+	return nil
+}
@@ -1,25 +1,32 @@
 package logger
 
-import (
-	"sync"
-
-	"go.uber.org/zap"
-)
-
 type LogEntry struct {
-	Timestamp int64  `json:"timestamp"`
-	Level     string `json:"level"`
-	Message   string `json:"message"`
+	Timestamp int64                  `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Session   string                 `json:"session,omitempty"`
+	Event     string                 `json:"event,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
 }
 
-type LoggerWithChannel struct {
-	Logger  *zap.Logger
-	LogChan chan LogEntry
-}
+// Reserved field keys, lifted out of the field set onto the LogEntry itself
+const (
+	SessionKey = "session"
+	EventKey   = "event"
+)
 
-// Pool for verbose loggers
-var LoggerPool = sync.Pool{
-	New: func() interface{} {
-		return CreateLogger()
-	},
-}
+// Event types attached to log entries, lets clients filter the stream
+const (
+	EventSessionStarted     = "session_started"
+	EventSessionEnded       = "session_ended"
+	EventClusterStarted     = "cluster_started"
+	EventClusterEnded       = "cluster_ended"
+	EventSessionReport      = "session_report"
+	EventTickStarted        = "tick_started"
+	EventCandidatesSelected = "candidates_selected"
+	EventVictimSelected     = "victim_selected"
+	EventPodDeleted         = "pod_deleted"
+	EventPodEvicted         = "pod_evicted"
+	EventPodSkipped         = "pod_skipped"
+	EventActionFailed       = "action_failed"
+)
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// AuditEntry represents an append-only record of an action taken against cascade
+type AuditEntry struct {
+	ID         int       `gorm:"primaryKey;column:audit_id" json:"id"`
+	ActorID    string    `gorm:"column:actor_id" json:"actor_id"`
+	Action     string    `gorm:"column:action;size:50;not null" json:"action"`
+	TargetType string    `gorm:"column:target_type;size:50;not null" json:"target_type"`
+	TargetID   string    `gorm:"column:target_id" json:"target_id"`
+	Diff       string    `gorm:"column:diff;type:text" json:"diff,omitempty"`
+	RequestID  string    `gorm:"column:request_id" json:"request_id"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP()" json:"created_at"`
+}
+
+// AuditFilter narrows down the audit entries to be listed, zero values are ignored
+type AuditFilter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// Audited actions
+const (
+	AuditScenarioCreate = "scenario.create"
+	AuditScenarioUpdate = "scenario.update"
+	AuditSessionStart   = "session.start"
+	AuditSessionEnd     = "session.end"
+	AuditKillSwitch     = "session.kill"
+	AuditTeamCreate     = "team.create"
+	AuditTeamUpdate     = "team.update"
+	AuditTeamDelete     = "team.delete"
+	AuditTeamAddUser    = "team.add_user"
+	AuditTeamRemoveUser = "team.remove_user"
+	AuditClusterCreate  = "cluster.create"
+	AuditClusterUpdate  = "cluster.update"
+	AuditClusterDelete  = "cluster.delete"
+	AuditUserRole       = "user.role"
+)
+
+// Audited target types
+const (
+	AuditTargetScenario = "scenario"
+	AuditTargetSession  = "session"
+	AuditTargetTeam     = "team"
+	AuditTargetCluster  = "cluster"
+	AuditTargetUser     = "user"
+)
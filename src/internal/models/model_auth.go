@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// APIKey represents a long-lived credential for non-interactive clients
+type APIKey struct {
+	ID         string     `gorm:"primaryKey;column:key_id" json:"id"`
+	Name       string     `gorm:"column:name;size:100;not null" json:"name"`
+	Prefix     string     `gorm:"column:prefix;size:20;not null" json:"prefix"`
+	Hash       string     `gorm:"column:key_hash;not null;unique" json:"-"`
+	UserID     string     `gorm:"column:user_id;not null" json:"user_id"`
+	TeamID     *string    `gorm:"column:team_id" json:"team_id,omitempty"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP()" json:"created_at"`
+}
+
+// RevokedToken represents a session token invalidated before its expiry
+type RevokedToken struct {
+	ID        string    `gorm:"primaryKey;column:token_id" json:"id"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Cluster represents a registered Kubernetes cluster, its credentials are encrypted at rest
+type Cluster struct {
+	ID          string    `gorm:"primaryKey;column:cluster_id" json:"id"`
+	Name        string    `gorm:"column:cluster_name;size:100;not null" json:"name"`
+	Server      string    `gorm:"column:server;not null" json:"server"`
+	Credentials []byte    `gorm:"column:credentials;not null" json:"-"`
+	TeamID      string    `gorm:"column:team_id;not null" json:"team_id"`
+	CreatedAt   time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP()" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;not null;default:CURRENT_TIMESTAMP()" json:"updated_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchemaMigration represents a migration applied onto the schema of the database
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;column:version;autoIncrement:false" json:"version"`
+	Name      string    `gorm:"column:name;not null" json:"name"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null" json:"applied_at"`
+}
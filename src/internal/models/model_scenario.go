@@ -12,10 +12,25 @@ type Scenario struct {
 	IncludedNodeNames string    `gorm:"column:includedNodeNames;type:text" json:"includedNodeNames"`
 	ExcludedPodNames  string    `gorm:"column:excludedPodNames;type:text" json:"excludedPodNames"`
 	Interval          string    `gorm:"column:interval;type:text" json:"interval"`
+	Distribution      string    `gorm:"column:distribution;type:text" json:"distribution,omitempty"`
+	MinInterval       string    `gorm:"column:min_interval;type:text" json:"minInterval,omitempty"`
+	MaxInterval       string    `gorm:"column:max_interval;type:text" json:"maxInterval,omitempty"`
+	IntervalJitter    string    `gorm:"column:interval_jitter;type:text" json:"intervalJitter,omitempty"`
+	InitialDelay      string    `gorm:"column:initial_delay;type:text" json:"initialDelay,omitempty"`
 	Grace             string    `gorm:"column:grace;type:text" json:"grace"`
 	Mode              string    `gorm:"column:mode;type:text" json:"mode"`
 	Ordering          string    `gorm:"column:ordering;type:text" json:"ordering"`
 	Ratio             float64   `gorm:"column:ratio;type:text" json:"ratio"`
+	Seed              string    `gorm:"column:seed;type:text" json:"seed,omitempty"`
+	Count             int       `gorm:"column:count" json:"count,omitempty"`
+	MinVictims        int       `gorm:"column:min_victims" json:"minVictims,omitempty"`
+	MaxVictims        int       `gorm:"column:max_victims" json:"maxVictims,omitempty"`
+	Rounding          string    `gorm:"column:rounding;type:text" json:"rounding,omitempty"`
+	Concurrency       int       `gorm:"column:concurrency" json:"concurrency,omitempty"`
+	RateLimit         float64   `gorm:"column:rate_limit" json:"rateLimit,omitempty"`
+	Stagger           string    `gorm:"column:stagger;type:text" json:"stagger,omitempty"`
+	Jitter            string    `gorm:"column:jitter;type:text" json:"jitter,omitempty"`
+	ClusterID         string    `gorm:"column:cluster_id" json:"cluster_id,omitempty"`
 	TeamID            string    `gorm:"column:team_id;not null" json:"team_id"`
 	CreatedAt         time.Time `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP()" json:"created_at"`
 	Team              Team      `gorm:"foreignKey:TeamID" json:"team"`
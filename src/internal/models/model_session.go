@@ -4,13 +4,32 @@ import "time"
 
 // Session represents a chaos engineering experiment during execution
 type Session struct {
-	ID         int       `gorm:"primaryKey;column:session_id" json:"id"`
-	ScenarioID string    `gorm:"column:scenario_id;not null" json:"scenario_id"`
-	UserID     string    `gorm:"column:user_id;not null" json:"user_id"`
-	StartTime  time.Time `gorm:"column:start_time;not null;default:CURRENT_TIMESTAMP()" json:"start_time"`
-	EndTime    time.Time `gorm:"column:end_time" json:"end_time"`
-	Status     string    `gorm:"column:status;size:20;not null" json:"status"`
-	Scenario   Scenario  `gorm:"foreignKey:ScenarioID" json:"scenario"`
-	Version    int       `gorm:"column:version;not null;default:1"`
-	User       User      `gorm:"foreignKey:UserID" json:"user"`
+	ID          int       `gorm:"primaryKey;column:session_id" json:"id"`
+	ScenarioID  string    `gorm:"column:scenario_id;not null" json:"scenario_id"`
+	UserID      string    `gorm:"column:user_id;not null" json:"user_id"`
+	ClusterID   string    `gorm:"column:cluster_id" json:"cluster_id,omitempty"`
+	FanOut      string    `gorm:"column:fanout;size:20" json:"fanout,omitempty"`
+	Seed        int64     `gorm:"column:seed;not null" json:"seed"`
+	Owner       string    `gorm:"column:owner" json:"owner,omitempty"`
+	HeartbeatAt time.Time `gorm:"column:heartbeat_at" json:"heartbeat_at"`
+	StartTime   time.Time `gorm:"column:start_time;not null;default:CURRENT_TIMESTAMP()" json:"start_time"`
+	EndTime     time.Time `gorm:"column:end_time" json:"end_time"`
+	Status      string    `gorm:"column:status;size:20;not null" json:"status"`
+	Scenario    Scenario  `gorm:"foreignKey:ScenarioID" json:"scenario"`
+	Version     int       `gorm:"column:version;not null;default:1"`
+	User        User      `gorm:"foreignKey:UserID" json:"user"`
+}
+
+// SessionCluster represents the status of a single cluster targeted by a session
+type SessionCluster struct {
+	SessionID int       `gorm:"primaryKey;column:session_id" json:"session_id"`
+	Cluster   string    `gorm:"primaryKey;column:cluster" json:"cluster"`
+	Position  int       `gorm:"column:position;not null" json:"position"`
+	Status    string    `gorm:"column:status;size:20;not null" json:"status"`
+	Rounds    int       `gorm:"column:rounds;not null" json:"rounds"`
+	Victims   int       `gorm:"column:victims;not null" json:"victims"`
+	Failures  int       `gorm:"column:failures;not null" json:"failures"`
+	Error     string    `gorm:"column:error" json:"error,omitempty"`
+	StartTime time.Time `gorm:"column:start_time" json:"start_time"`
+	EndTime   time.Time `gorm:"column:end_time" json:"end_time"`
 }
@@ -12,6 +12,19 @@ type Team struct {
 }
 
 type UserTeam struct {
-	UserID int `gorm:"primaryKey;column:user_id" json:"user_id"`
-	TeamID int `gorm:"primaryKey;column:team_id" json:"team_id"`
+	UserID string `gorm:"primaryKey;column:user_id" json:"user_id"`
+	TeamID string `gorm:"primaryKey;column:team_id" json:"team_id"`
+	Role   string `gorm:"column:role;size:20;not null;default:'member'" json:"role"`
 }
+
+// TeamMember represents a user along with their role within the team
+type TeamMember struct {
+	User
+	Membership string `gorm:"column:membership" json:"membership"`
+}
+
+// Membership roles within a team
+const (
+	MembershipOwner  = "owner"
+	MembershipMember = "member"
+)
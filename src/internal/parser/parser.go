@@ -1,13 +1,14 @@
 package parser
 
 import (
+	"encoding/json"
 	"io"
 	"strconv"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/wizenheimer/cascade/internal/config"
 	"github.com/wizenheimer/cascade/internal/models"
+	"github.com/wizenheimer/cascade/internal/secret"
 	k8x "github.com/wizenheimer/cascade/service/kubernetes"
 	"gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/labels"
@@ -16,6 +17,24 @@ import (
 // Parse DB Scenario
 func ParseDBScenario(scenario models.Scenario) (*k8x.TargetConfig, *k8x.RuntimeConfig, error) {
 	// Convert it into an intermediate config
+	cfg := ParseScenarioToConfig(scenario)
+
+	tc, err := ParseTargetConfig(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err := ParseRuntimeConfig(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc.Ratio = scenario.Ratio
+
+	return tc, rc, nil
+}
+
+// Converts a stored scenario back onto the config it was created from, such as for exporting it as YAML
+func ParseScenarioToConfig(scenario models.Scenario) config.Config {
 	scenarioConfig := config.Scenario{
 		ID:          scenario.ID,
 		Description: scenario.Description,
@@ -33,26 +52,36 @@ func ParseDBScenario(scenario models.Scenario) (*k8x.TargetConfig, *k8x.RuntimeC
 		Grace:    scenario.Grace,
 		Mode:     scenario.Mode,
 		Ordering: scenario.Ordering,
-	}
-
-	cfg := config.Config{
+		Seed:     scenario.Seed,
+		// Zero counts are left unset
+		Count:      formatVictims(scenario.Count),
+		MinVictims: formatVictims(scenario.MinVictims),
+		MaxVictims: formatVictims(scenario.MaxVictims),
+		Rounding:   scenario.Rounding,
+		// Unset action limits fall back onto their defaults
+		Concurrency: formatVictims(scenario.Concurrency),
+		RateLimit:   formatRateLimit(scenario.RateLimit),
+		Stagger:     scenario.Stagger,
+		Jitter:      scenario.Jitter,
+		// Unset schedule fields fall back onto their defaults
+		Distribution:   scenario.Distribution,
+		MinInterval:    scenario.MinInterval,
+		MaxInterval:    scenario.MaxInterval,
+		IntervalJitter: scenario.IntervalJitter,
+		InitialDelay:   scenario.InitialDelay,
+	}
+
+	// Zero ratios are left unset
+	if scenario.Ratio != 0 {
+		runtimeConfig.Ratio = strconv.FormatFloat(scenario.Ratio, 'g', -1, 64)
+	}
+
+	return config.Config{
 		Scenario: scenarioConfig,
 		Target:   targetConfig,
 		Runtime:  runtimeConfig,
+		Cluster:  config.Cluster{ID: scenario.ClusterID},
 	}
-
-	tc, err := ParseTargetConfig(&cfg)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	rc, err := ParseRuntimeConfig(&cfg)
-	if err != nil {
-		return nil, nil, err
-	}
-	rc.Ratio = scenario.Ratio
-
-	return tc, rc, nil
 }
 
 func ParseYAMLConfigToScenario(cfg *config.Config) (*models.Scenario, error) {
@@ -61,22 +90,57 @@ func ParseYAMLConfigToScenario(cfg *config.Config) (*models.Scenario, error) {
 		Namespaces:  cfg.Target.Namespaces,
 	}
 
+	scenario.ClusterID = cfg.Cluster.ID
+
 	scenario.IncludedPodNames = cfg.Target.IncludedPodNames
 	scenario.IncludedNodeNames = cfg.Target.IncludedNodeNames
 	scenario.ExcludedPodNames = cfg.Target.ExcludedPodNames
 
 	scenario.Interval = cfg.Runtime.Interval
+	scenario.Distribution = cfg.Runtime.Distribution
+	scenario.MinInterval = cfg.Runtime.MinInterval
+	scenario.MaxInterval = cfg.Runtime.MaxInterval
+	scenario.IntervalJitter = cfg.Runtime.IntervalJitter
+	scenario.InitialDelay = cfg.Runtime.InitialDelay
 	scenario.Grace = cfg.Runtime.Grace
 	scenario.Mode = cfg.Runtime.Mode
 	scenario.Ordering = cfg.Runtime.Ordering
+	scenario.Seed = cfg.Runtime.Seed
+	scenario.Rounding = cfg.Runtime.Rounding
 
-	ratioStr := cfg.Runtime.Ratio
-	ratio, err := strconv.ParseFloat(ratioStr, 64)
+	// Parse victim counts, unset counts are stored as zero
+	var bounds k8x.RuntimeConfig
+	err := parseVictimBounds(&bounds, cfg.Runtime.Count, cfg.Runtime.MinVictims, cfg.Runtime.MaxVictims, cfg.Runtime.Rounding)
 	if err != nil {
 		return nil, err
 	}
+	scenario.Count = bounds.Count
+	scenario.MinVictims = bounds.MinVictims
+	scenario.MaxVictims = bounds.MaxVictims
 
-	scenario.Ratio = ratio
+	// Parse action limits, unset limits are stored as zero so they follow the defaults
+	if cfg.Runtime.Concurrency != "" {
+		if scenario.Concurrency, err = parseConcurrency(cfg.Runtime.Concurrency); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Runtime.RateLimit != "" {
+		if scenario.RateLimit, err = parseRateLimit(cfg.Runtime.RateLimit); err != nil {
+			return nil, err
+		}
+	}
+	scenario.Stagger = cfg.Runtime.Stagger
+	scenario.Jitter = cfg.Runtime.Jitter
+
+	// Scenarios left without a ratio are stored with the default one
+	ratioStr := cfg.Runtime.Ratio
+	if ratioStr == "" {
+		ratioStr = config.GetEnv("RATIO", config.RATIO)
+	}
+	scenario.Ratio, err = parseRatio(ratioStr)
+	if err != nil {
+		return nil, err
+	}
 
 	return &scenario, nil
 }
@@ -90,8 +154,8 @@ func ParseTargetConfig(cfg *config.Config) (*k8x.TargetConfig, error) {
 
 	return &k8x.TargetConfig{
 		Namespaces:        namespaces,
-		IncludedPodNames:  cfg.Target.IncludedNodeNames,
-		IncludedNodeNames: cfg.Target.IncludedPodNames,
+		IncludedPodNames:  cfg.Target.IncludedPodNames,
+		IncludedNodeNames: cfg.Target.IncludedNodeNames,
 		ExcludedPodNames:  cfg.Target.ExcludedPodNames,
 	}, nil
 }
@@ -112,6 +176,38 @@ func ParseClusterConfigFromContext(c echo.Context) (*k8x.ClusterConfig, error) {
 	}, nil
 }
 
+// Parse the Cluster Config of a registered cluster, decrypting its credentials
+func ParseRegisteredCluster(cluster *models.Cluster, sealer *secret.Sealer, healthcheck string) (*k8x.ClusterConfig, error) {
+	plaintext, err := sealer.Open(cluster.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds k8x.ClusterCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, err
+	}
+
+	if healthcheck == "" {
+		healthcheck = config.GetEnv("HEALTH_CHECK_PORT", config.HEALTH_CHECK_PORT)
+	}
+
+	return &k8x.ClusterConfig{
+		Server:      cluster.Server,
+		Credentials: &creds,
+		Healthcheck: healthcheck,
+	}, nil
+}
+
+// Encrypt the credentials of a cluster for storing them at rest
+func SealClusterCredentials(creds *k8x.ClusterCredentials, sealer *secret.Sealer) ([]byte, error) {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+	return sealer.Seal(plaintext)
+}
+
 // Fetch Cluster specific Configurations from Echo's Context
 func ParseClusterConfig(cfg *config.Config) (*k8x.ClusterConfig, error) {
 	kubeconfig := cfg.Cluster.Kubeconfig
@@ -128,6 +224,77 @@ func ParseClusterConfig(cfg *config.Config) (*k8x.ClusterConfig, error) {
 	}, nil
 }
 
+// Fetch the Cluster Configs of every kube context listed, falls back to a single Cluster Config
+func ParseClusterConfigs(cfg *config.Config) ([]*k8x.ClusterConfig, error) {
+	cc, err := ParseClusterConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Cluster.Contexts) == 0 {
+		return []*k8x.ClusterConfig{cc}, nil
+	}
+
+	configs := make([]*k8x.ClusterConfig, len(cfg.Cluster.Contexts))
+	for i, context := range cfg.Cluster.Contexts {
+		scoped := *cc
+		scoped.Context = context
+		configs[i] = &scoped
+	}
+	return configs, nil
+}
+
+// Fetch Fan-out Configurations from Echo's Context
+func ParseFanOutConfigFromContext(c echo.Context) (*k8x.FanOutConfig, error) {
+	return ParseFanOutConfig(&config.Config{
+		FanOut: config.FanOut{
+			Strategy: c.FormValue("fanout"),
+			Pause:    c.FormValue("pause"),
+			Rounds:   c.FormValue("rounds"),
+		},
+	})
+}
+
+// Fetch Fan-out Configurations
+func ParseFanOutConfig(cfg *config.Config) (*k8x.FanOutConfig, error) {
+	strategyStr := cfg.FanOut.Strategy
+	if strategyStr == "" {
+		strategyStr = config.FANOUT_STRATEGY
+	}
+	pauseStr := cfg.FanOut.Pause
+	if pauseStr == "" {
+		pauseStr = config.FANOUT_PAUSE
+	}
+
+	roundsStr := cfg.FanOut.Rounds
+	if roundsStr == "" {
+		roundsStr = config.FANOUT_ROUNDS
+	}
+
+	// Parse strategy
+	strategy, err := k8x.ParseFanOutStrategy(strategyStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse pause
+	pause, err := parsePause(pauseStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse rounds
+	rounds, err := parseRounds(roundsStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k8x.FanOutConfig{
+		Strategy: strategy,
+		Pause:    pause,
+		Rounds:   rounds,
+	}, nil
+}
+
 // Fetch Runtime Configurations from Echo's Context
 func ParseRuntimeConfig(cfg *config.Config) (*k8x.RuntimeConfig, error) {
 	intervalStr := cfg.Runtime.Interval
@@ -156,36 +323,72 @@ func ParseRuntimeConfig(cfg *config.Config) (*k8x.RuntimeConfig, error) {
 	}
 
 	// Parse Ordering
-	ordering := k8x.ParseOrderingStrategy(orderStr)
+	ordering, err := k8x.ParseOrderingStrategy(orderStr)
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse interval
-	interval, err := time.ParseDuration(intervalStr)
+	interval, err := parseInterval(intervalStr)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse grace
-	grace, err := strconv.ParseInt(graceStr, 10, 64)
+	grace, err := parseGrace(graceStr)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse ratio
-	ratio, err := strconv.ParseFloat(ratioStr, 64)
+	ratio, err := parseRatio(ratioStr)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert modeStr to ExecutionMode enum
-	mode := k8x.ParseExecutionMode(modeStr)
+	mode, err := k8x.ParseExecutionMode(modeStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse seed, sessions left without one are seeded randomly
+	seed := GenerateSeed()
+	if cfg.Runtime.Seed != "" {
+		seed, err = ParseSeed(cfg.Runtime.Seed)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return &k8x.RuntimeConfig{
+	rc := &k8x.RuntimeConfig{
 		Interval: interval,
 		Ratio:    ratio,
 		Mode:     mode,
 		Grace:    grace,
 		Order:    ordering,
-	}, nil
+		Seed:     seed,
+	}
+
+	// Parse victim counts and rounding
+	err = parseVictimBounds(rc, cfg.Runtime.Count, cfg.Runtime.MinVictims, cfg.Runtime.MaxVictims, cfg.Runtime.Rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse concurrency and rate limits of victim actions
+	err = parseActionLimits(rc, cfg.Runtime.Concurrency, cfg.Runtime.RateLimit, cfg.Runtime.Stagger, cfg.Runtime.Jitter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the schedule of ticks
+	err = parseSchedule(rc, cfg.Runtime.Distribution, cfg.Runtime.MinInterval, cfg.Runtime.MaxInterval, cfg.Runtime.IntervalJitter, cfg.Runtime.InitialDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	return rc, nil
 }
 
 // Parse Chaos Engineering Configs from Echo's Context
@@ -233,6 +436,9 @@ func parseNamespaces(str string) (labels.Selector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := k8x.ValidateNamespaceSelector(selector); err != nil {
+		return nil, err
+	}
 	return selector, nil
 }
 
@@ -311,27 +517,41 @@ func ParseConfigsFromContext(c echo.Context) (*k8x.ClusterConfig, *k8x.TargetCon
 	}
 
 	// Parse Ordering
-	ordering := k8x.ParseOrderingStrategy(orderStr)
+	ordering, err := k8x.ParseOrderingStrategy(orderStr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	// Parse interval
-	interval, err := time.ParseDuration(intervalStr)
+	interval, err := parseInterval(intervalStr)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// Parse ratio
-	ratio, err := strconv.ParseFloat(ratioStr, 64)
+	ratio, err := parseRatio(ratioStr)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// Parse grace
-	grace, err := strconv.ParseInt(graceStr, 10, 64)
+	grace, err := parseGrace(graceStr)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	mode := k8x.ParseExecutionMode(modeStr)
+	mode, err := k8x.ParseExecutionMode(modeStr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	seed := GenerateSeed()
+	if seedStr := c.FormValue("seed"); seedStr != "" {
+		seed, err = ParseSeed(seedStr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
 
 	runtimeConfig := &k8x.RuntimeConfig{
 		Interval: interval,
@@ -339,6 +559,22 @@ func ParseConfigsFromContext(c echo.Context) (*k8x.ClusterConfig, *k8x.TargetCon
 		Mode:     mode,
 		Grace:    grace,
 		Order:    ordering,
+		Seed:     seed,
+	}
+
+	err = parseVictimBounds(runtimeConfig, c.FormValue("count"), c.FormValue("minVictims"), c.FormValue("maxVictims"), c.FormValue("rounding"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = parseActionLimits(runtimeConfig, c.FormValue("concurrency"), c.FormValue("rateLimit"), c.FormValue("stagger"), c.FormValue("jitter"))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = parseSchedule(runtimeConfig, c.FormValue("distribution"), c.FormValue("minInterval"), c.FormValue("maxInterval"), c.FormValue("intervalJitter"), c.FormValue("initialDelay"))
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	return clusterConfig, targetConfig, runtimeConfig, nil
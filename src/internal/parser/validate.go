@@ -0,0 +1,512 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wizenheimer/cascade/internal/config"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"gopkg.in/yaml.v2"
+)
+
+// Error found at a field of a scenario
+type FieldError struct {
+	// Path of the field, such as runtime.grace, empty for errors spanning the document
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Every error found across a scenario
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Decodes and validates a YAML scenario, unknown fields are reported alongside invalid ones
+func ValidateYAML(data []byte) (*config.Config, ValidationErrors) {
+	cfg, errs := DecodeYAML(data)
+	if cfg == nil {
+		return nil, errs
+	}
+	return cfg, append(errs, ValidateConfig(cfg)...)
+}
+
+// Decodes a YAML scenario without validating its fields, unknown fields are reported.
+// The config is nil if the document couldn't be decoded at all
+func DecodeYAML(data []byte) (*config.Config, ValidationErrors) {
+	var cfg config.Config
+	var errs ValidationErrors
+
+	err := yaml.UnmarshalStrict(data, &cfg)
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		// Fields which could be decoded are still validated
+		for _, message := range typeErr.Errors {
+			errs = append(errs, FieldError{Message: message})
+		}
+	case err != nil:
+		return nil, ValidationErrors{{Message: err.Error()}}
+	}
+
+	return &cfg, errs
+}
+
+// Validates every field of the config, empty fields fall back onto their defaults
+func ValidateConfig(cfg *config.Config) ValidationErrors {
+	var v validator
+
+	// Target
+	v.check("target.namespaces", cfg.Target.Namespaces, func(s string) error {
+		_, err := parseNamespaces(s)
+		return err
+	})
+	v.check("target.includedPodNames", cfg.Target.IncludedPodNames, parseNameList)
+	v.check("target.includedNodeNames", cfg.Target.IncludedNodeNames, parseNameList)
+	v.check("target.excludedPodNames", cfg.Target.ExcludedPodNames, parseNameList)
+
+	// Runtime
+	v.check("runtime.interval", cfg.Runtime.Interval, func(s string) error {
+		_, err := parseInterval(s)
+		return err
+	})
+	v.check("runtime.distribution", cfg.Runtime.Distribution, func(s string) error {
+		_, err := k8x.ParseIntervalDistribution(s)
+		return err
+	})
+	v.check("runtime.minInterval", cfg.Runtime.MinInterval, func(s string) error {
+		_, err := parseDelay("minInterval", s)
+		return err
+	})
+	v.check("runtime.maxInterval", cfg.Runtime.MaxInterval, func(s string) error {
+		maxInterval, err := parseDelay("maxInterval", s)
+		if err != nil || cfg.Runtime.MinInterval == "" {
+			return err
+		}
+		// Left unreported if the minimum itself is invalid
+		minInterval, err := parseDelay("minInterval", cfg.Runtime.MinInterval)
+		if err == nil && minInterval > maxInterval {
+			return fmt.Errorf("maxInterval must not be lower than minInterval, got %s and %s", s, cfg.Runtime.MinInterval)
+		}
+		return nil
+	})
+	if cfg.Runtime.Distribution == k8x.Uniform.String() && (cfg.Runtime.MinInterval == "" || cfg.Runtime.MaxInterval == "") {
+		v.errs = append(v.errs, FieldError{Field: "runtime.distribution", Value: cfg.Runtime.Distribution, Message: "uniform intervals require both minInterval and maxInterval"})
+	}
+	v.check("runtime.intervalJitter", cfg.Runtime.IntervalJitter, func(s string) error {
+		_, err := parseIntervalJitter(s)
+		return err
+	})
+	v.check("runtime.initialDelay", cfg.Runtime.InitialDelay, func(s string) error {
+		_, err := parseDelay("initialDelay", s)
+		return err
+	})
+	v.check("runtime.grace", cfg.Runtime.Grace, func(s string) error {
+		_, err := parseGrace(s)
+		return err
+	})
+	v.check("runtime.ratio", cfg.Runtime.Ratio, func(s string) error {
+		_, err := parseRatio(s)
+		return err
+	})
+	v.check("runtime.mode", cfg.Runtime.Mode, func(s string) error {
+		_, err := k8x.ParseExecutionMode(s)
+		return err
+	})
+	v.check("runtime.ordering", cfg.Runtime.Ordering, func(s string) error {
+		_, err := k8x.ParseOrderingStrategy(s)
+		return err
+	})
+	v.check("runtime.seed", cfg.Runtime.Seed, func(s string) error {
+		_, err := ParseSeed(s)
+		return err
+	})
+	v.check("runtime.count", cfg.Runtime.Count, func(s string) error {
+		_, err := parseVictims(s)
+		return err
+	})
+	v.check("runtime.minVictims", cfg.Runtime.MinVictims, func(s string) error {
+		_, err := parseVictims(s)
+		return err
+	})
+	v.check("runtime.maxVictims", cfg.Runtime.MaxVictims, func(s string) error {
+		maxVictims, err := parseVictims(s)
+		if err != nil || cfg.Runtime.MinVictims == "" {
+			return err
+		}
+		// Left unreported if the minimum itself is invalid
+		minVictims, err := parseVictims(cfg.Runtime.MinVictims)
+		if err == nil && maxVictims > 0 && minVictims > maxVictims {
+			return fmt.Errorf("maxVictims must not be lower than minVictims, got %d and %d", maxVictims, minVictims)
+		}
+		return nil
+	})
+	v.check("runtime.rounding", cfg.Runtime.Rounding, func(s string) error {
+		_, err := k8x.ParseRoundingMode(s)
+		return err
+	})
+	v.check("runtime.concurrency", cfg.Runtime.Concurrency, func(s string) error {
+		_, err := parseConcurrency(s)
+		return err
+	})
+	v.check("runtime.rateLimit", cfg.Runtime.RateLimit, func(s string) error {
+		_, err := parseRateLimit(s)
+		return err
+	})
+	v.check("runtime.stagger", cfg.Runtime.Stagger, func(s string) error {
+		_, err := parseDelay("stagger", s)
+		return err
+	})
+	v.check("runtime.jitter", cfg.Runtime.Jitter, func(s string) error {
+		_, err := parseDelay("jitter", s)
+		return err
+	})
+
+	// Cluster
+	v.check("cluster.id", cfg.Cluster.ID, func(s string) error {
+		if _, err := uuid.Parse(s); err != nil {
+			return fmt.Errorf("invalid cluster ID %q, expected a UUID", s)
+		}
+		return nil
+	})
+	v.check("cluster.origin", cfg.Cluster.Origin, func(s string) error {
+		if s != "host" && s != "cluster" {
+			return fmt.Errorf("unknown origin %q, expected host or cluster", s)
+		}
+		return nil
+	})
+	seen := make(map[string]bool, len(cfg.Cluster.Contexts))
+	for i, context := range cfg.Cluster.Contexts {
+		field := fmt.Sprintf("cluster.contexts[%d]", i)
+		switch {
+		case context == "":
+			v.errs = append(v.errs, FieldError{Field: field, Message: "context must not be empty"})
+		case seen[context]:
+			v.errs = append(v.errs, FieldError{Field: field, Value: context, Message: fmt.Sprintf("context %q is listed more than once", context)})
+		}
+		seen[context] = true
+	}
+	if cfg.Cluster.ID != "" && len(cfg.Cluster.Contexts) > 0 {
+		v.errs = append(v.errs, FieldError{Field: "cluster.contexts", Message: "contexts can't be combined with a registered cluster"})
+	}
+
+	// Fan-out
+	v.check("fanout.strategy", cfg.FanOut.Strategy, func(s string) error {
+		_, err := k8x.ParseFanOutStrategy(s)
+		return err
+	})
+	v.check("fanout.pause", cfg.FanOut.Pause, func(s string) error {
+		_, err := parsePause(s)
+		return err
+	})
+	v.check("fanout.rounds", cfg.FanOut.Rounds, func(s string) error {
+		_, err := parseRounds(s)
+		return err
+	})
+
+	return v.errs
+}
+
+// Collects errors across fields rather than stopping at the first one
+type validator struct {
+	errs ValidationErrors
+}
+
+func (v *validator) check(field string, value string, parse func(string) error) {
+	if value == "" {
+		return
+	}
+	if err := parse(value); err != nil {
+		v.errs = append(v.errs, FieldError{Field: field, Value: value, Message: err.Error()})
+	}
+}
+
+// Parse a comma separated list of names, empty entries would match every name
+func parseNameList(str string) error {
+	for _, name := range strings.Split(str, ",") {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid name list %q, entries must not be empty", str)
+		}
+	}
+	return nil
+}
+
+// Parse the interval between ticks
+func parseInterval(str string) (time.Duration, error) {
+	interval, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q, expected a duration such as 30s or 10m", str)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive, got %s", str)
+	}
+	return interval, nil
+}
+
+// Parse the spread of intervals, either as a percentage such as 20% or a fraction such as 0.2
+func parseIntervalJitter(str string) (float64, error) {
+	number, percent := strings.CutSuffix(str, "%")
+	jitter, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval jitter %q, expected a percentage such as 20%% or a fraction such as 0.2", str)
+	}
+	if percent {
+		jitter /= 100
+	}
+	if jitter < 0 || jitter > 1 {
+		return 0, fmt.Errorf("interval jitter must be within [0%%, 100%%], got %s", str)
+	}
+	return jitter, nil
+}
+
+// Parse the distribution, bounds, jitter and initial delay of ticks onto the runtime config, unset ones fall back onto their defaults
+func parseSchedule(rc *k8x.RuntimeConfig, distributionStr, minStr, maxStr, jitterStr, initialDelayStr string) error {
+	var err error
+	if distributionStr == "" {
+		distributionStr = config.GetEnv("INTERVAL_DISTRIBUTION", config.INTERVAL_DISTRIBUTION)
+	}
+	if rc.Distribution, err = k8x.ParseIntervalDistribution(distributionStr); err != nil {
+		return err
+	}
+
+	if minStr != "" {
+		if rc.MinInterval, err = parseDelay("minInterval", minStr); err != nil {
+			return err
+		}
+	}
+	if maxStr != "" {
+		if rc.MaxInterval, err = parseDelay("maxInterval", maxStr); err != nil {
+			return err
+		}
+	}
+	if rc.MaxInterval > 0 && rc.MinInterval > rc.MaxInterval {
+		return fmt.Errorf("maxInterval must not be lower than minInterval, got %s and %s", rc.MaxInterval, rc.MinInterval)
+	}
+	if rc.Distribution == k8x.Uniform && rc.MaxInterval == 0 {
+		return fmt.Errorf("uniform intervals require both minInterval and maxInterval")
+	}
+
+	if jitterStr == "" {
+		jitterStr = config.GetEnv("INTERVAL_JITTER", config.INTERVAL_JITTER)
+	}
+	if rc.IntervalJitter, err = parseIntervalJitter(jitterStr); err != nil {
+		return err
+	}
+
+	if initialDelayStr == "" {
+		initialDelayStr = config.GetEnv("INITIAL_DELAY", config.INITIAL_DELAY)
+	}
+	rc.InitialDelay, err = parseDelay("initialDelay", initialDelayStr)
+	return err
+}
+
+// Parse the grace period in seconds, either as a whole number of seconds or as a duration
+func parseGrace(str string) (int64, error) {
+	seconds, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		grace, err := time.ParseDuration(str)
+		if err != nil {
+			return 0, fmt.Errorf("invalid grace %q, expected seconds or a duration such as 30s or 1m", str)
+		}
+		if grace%time.Second != 0 {
+			return 0, fmt.Errorf("grace must be a whole number of seconds, got %s", str)
+		}
+		seconds = int64(grace / time.Second)
+	}
+	if seconds < 0 {
+		return 0, fmt.Errorf("grace must not be negative, got %s", str)
+	}
+	return seconds, nil
+}
+
+// Parse the ratio of candidates to act upon
+func parseRatio(str string) (float64, error) {
+	ratio, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ratio %q, expected a number", str)
+	}
+	if ratio <= 0 || ratio > 1 {
+		return 0, fmt.Errorf("ratio must be within (0, 1], got %s", str)
+	}
+	return ratio, nil
+}
+
+// Parse the seed of victim selection
+func ParseSeed(str string) (int64, error) {
+	seed, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid seed %q, expected a whole number", str)
+	}
+	return seed, nil
+}
+
+// Generates a seed for sessions left without one
+func GenerateSeed() int64 {
+	return rand.Int64()
+}
+
+// Parse a number of victims
+func parseVictims(str string) (int, error) {
+	victims, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of victims %q, expected a whole number", str)
+	}
+	if victims < 0 {
+		return 0, fmt.Errorf("number of victims must not be negative, got %d", victims)
+	}
+	return victims, nil
+}
+
+// Formats a number of victims, zero is left unset
+func formatVictims(victims int) string {
+	if victims == 0 {
+		return ""
+	}
+	return strconv.Itoa(victims)
+}
+
+// Formats a rate limit, zero is left unset
+func formatRateLimit(limit float64) string {
+	if limit == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(limit, 'g', -1, 64)
+}
+
+// Parse the victim count, bounds and rounding onto the runtime config, unset ones are left as is
+func parseVictimBounds(rc *k8x.RuntimeConfig, countStr, minStr, maxStr, roundingStr string) error {
+	var err error
+	for _, field := range []struct {
+		str string
+		dst *int
+	}{
+		{countStr, &rc.Count},
+		{minStr, &rc.MinVictims},
+		{maxStr, &rc.MaxVictims},
+	} {
+		if field.str == "" {
+			continue
+		}
+		if *field.dst, err = parseVictims(field.str); err != nil {
+			return err
+		}
+	}
+	if rc.MaxVictims > 0 && rc.MinVictims > rc.MaxVictims {
+		return fmt.Errorf("maxVictims must not be lower than minVictims, got %d and %d", rc.MaxVictims, rc.MinVictims)
+	}
+
+	if roundingStr == "" {
+		roundingStr = config.GetEnv("ROUNDING", config.ROUNDING)
+	}
+	rc.Rounding, err = k8x.ParseRoundingMode(roundingStr)
+	return err
+}
+
+// Parse the number of victims acted upon at once
+func parseConcurrency(str string) (int, error) {
+	concurrency, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid concurrency %q, expected a whole number", str)
+	}
+	if concurrency < 1 {
+		return 0, fmt.Errorf("concurrency must be at least 1, got %d", concurrency)
+	}
+	return concurrency, nil
+}
+
+// Parse the actions started per second
+func parseRateLimit(str string) (float64, error) {
+	limit, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate limit %q, expected actions per second", str)
+	}
+	if limit < 0 {
+		return 0, fmt.Errorf("rate limit must not be negative, got %s", str)
+	}
+	return limit, nil
+}
+
+// Parse a delay between actions, such as the stagger or the jitter
+func parseDelay(name string, str string) (time.Duration, error) {
+	delay, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration such as 500ms or 2s", name, str)
+	}
+	if delay < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %s", name, str)
+	}
+	return delay, nil
+}
+
+// Parse the concurrency, rate limit, stagger and jitter onto the runtime config, unset ones fall back onto their defaults
+func parseActionLimits(rc *k8x.RuntimeConfig, concurrencyStr, rateLimitStr, staggerStr, jitterStr string) error {
+	var err error
+	if concurrencyStr == "" {
+		concurrencyStr = config.GetEnv("CONCURRENCY", config.CONCURRENCY)
+	}
+	if rc.Concurrency, err = parseConcurrency(concurrencyStr); err != nil {
+		return err
+	}
+
+	if rateLimitStr == "" {
+		rateLimitStr = config.GetEnv("RATE_LIMIT", config.RATE_LIMIT)
+	}
+	if rc.RateLimit, err = parseRateLimit(rateLimitStr); err != nil {
+		return err
+	}
+
+	if staggerStr == "" {
+		staggerStr = config.GetEnv("STAGGER", config.STAGGER)
+	}
+	if rc.Stagger, err = parseDelay("stagger", staggerStr); err != nil {
+		return err
+	}
+
+	if jitterStr == "" {
+		jitterStr = config.GetEnv("JITTER", config.JITTER)
+	}
+	rc.Jitter, err = parseDelay("jitter", jitterStr)
+	return err
+}
+
+// Parse the pause between clusters
+func parsePause(str string) (time.Duration, error) {
+	pause, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pause %q, expected a duration such as 30s or 10m", str)
+	}
+	if pause < 0 {
+		return 0, fmt.Errorf("pause must not be negative, got %s", str)
+	}
+	return pause, nil
+}
+
+// Parse the rounds run per cluster
+func parseRounds(str string) (int, error) {
+	rounds, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rounds %q, expected a whole number", str)
+	}
+	if rounds < 0 {
+		return 0, fmt.Errorf("rounds must not be negative, got %d", rounds)
+	}
+	return rounds, nil
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/wizenheimer/cascade/internal/config"
+)
+
+func TestValidateConfigNamespaces(t *testing.T) {
+	tests := []struct {
+		namespaces string
+		valid      bool
+	}{
+		{namespaces: "", valid: true},
+		{namespaces: "checkout", valid: true},
+		{namespaces: "checkout,payments", valid: true},
+		{namespaces: "!kube-system", valid: true},
+		{namespaces: "checkout,!kube-system", valid: true},
+
+		// Namespaces are matched by name alone, selectors on values never match
+		{namespaces: "env=prod", valid: false},
+		{namespaces: "env!=prod", valid: false},
+		{namespaces: "env in (prod,staging)", valid: false},
+		{namespaces: "checkout,env notin (prod)", valid: false},
+
+		// Not a selector at all
+		{namespaces: "checkout,,", valid: false},
+	}
+
+	for _, tt := range tests {
+		cfg := &config.Config{Target: config.Target{Namespaces: tt.namespaces}}
+
+		var found bool
+		for _, err := range ValidateConfig(cfg) {
+			if err.Field == "target.namespaces" {
+				found = true
+			}
+		}
+		if found == tt.valid {
+			t.Errorf("ValidateConfig(namespaces %q) valid = %v, want %v", tt.namespaces, !found, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package rbac
+
+import "github.com/wizenheimer/cascade/internal/config"
+
+// Permission represents an action a user may be allowed to take
+type Permission string
+
+const (
+	ReadScenario       Permission = "scenario:read"       // View scenarios, their versions and metrics
+	WriteScenario      Permission = "scenario:write"      // Create and update scenarios
+	RunDryRun          Permission = "session:dry-run"     // Start sessions which only simulate chaos
+	RunDestructive     Permission = "session:destructive" // Start sessions which delete or evict pods
+	KillSession        Permission = "session:kill"        // Abort running sessions using the kill switch
+	ReadTeam           Permission = "team:read"           // View teams and their members
+	CreateTeam         Permission = "team:create"         // Create new teams
+	ManageTeam         Permission = "team:manage"         // Update teams and manage their membership
+	ReadAudit          Permission = "audit:read"          // View the audit log
+	ReadCluster        Permission = "cluster:read"        // View registered clusters and run scenarios against them
+	ManageCluster      Permission = "cluster:manage"      // Register, update and remove clusters
+	ManageUsers        Permission = "user:manage"         // Change the role of users
+	ManageAllResources Permission = "*"                   // Bypasses team scoping
+)
+
+// Role represents the role of a user, as stored in cascade.users.role
+type Role string
+
+const (
+	User    Role = "user"
+	Manager Role = "manager"
+	Admin   Role = "admin"
+)
+
+// Permissions granted to each role
+// Apart from admins, permissions only apply within the teams a user belongs to
+var rolePermissions = map[Role][]Permission{
+	User: {
+		ReadScenario,
+		RunDryRun,
+		ReadTeam,
+		ReadCluster,
+	},
+	Manager: {
+		ReadScenario,
+		WriteScenario,
+		RunDryRun,
+		RunDestructive,
+		KillSession,
+		ReadTeam,
+		CreateTeam,
+		ManageTeam,
+		ReadCluster,
+		ManageCluster,
+	},
+	Admin: {
+		ManageAllResources,
+	},
+}
+
+// Permissions granted on top of the user's role, within the teams they own
+var membershipPermissions = map[string][]Permission{
+	"owner": {
+		ManageTeam,
+	},
+}
+
+// Checks if the role grants the permission
+func Allowed(role string, permission Permission) bool {
+	return granted(rolePermissions[Role(role)], permission)
+}
+
+// Checks if either the role or the membership within the team grants the permission
+func AllowedWithin(role string, membership string, permission Permission) bool {
+	return Allowed(role, permission) || granted(membershipPermissions[membership], permission)
+}
+
+func granted(permissions []Permission, permission Permission) bool {
+	for _, granted := range permissions {
+		if granted == permission || granted == ManageAllResources {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the role is one of user, manager or admin
+func Valid(role string) bool {
+	_, ok := rolePermissions[Role(role)]
+	return ok
+}
+
+// Checks if the role is exempt from team scoping
+func Unscoped(role string) bool {
+	return Allowed(role, ManageAllResources)
+}
+
+// Returns the permission required to run a session in the given mode
+// Anything other than a dry run is treated as destructive
+func SessionPermission(mode string) Permission {
+	if mode == "" {
+		mode = config.GetEnv("MODE", config.MODE)
+	}
+	if mode == "dry-run" {
+		return RunDryRun
+	}
+	return RunDestructive
+}
@@ -0,0 +1,55 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+var ErrMalformed = errors.New("malformed ciphertext")
+
+// Encrypts secrets at rest using AES-256-GCM
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// Instantiates a sealer, the key is stretched onto 32 bytes using SHA-256
+func NewSealer(key string) (*Sealer, error) {
+	if key == "" {
+		return nil, errors.New("secret key must not be empty")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealer{aead: aead}, nil
+}
+
+// Encrypts the plaintext, the nonce is prepended onto the ciphertext
+func (s *Sealer) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypts a ciphertext produced by Seal
+func (s *Sealer) Open(ciphertext []byte) ([]byte, error) {
+	size := s.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, ErrMalformed
+	}
+	return s.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
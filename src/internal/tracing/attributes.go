@@ -0,0 +1,19 @@
+package tracing
+
+import "go.opentelemetry.io/otel/attribute"
+
+// Attribute keys attached to cascade spans
+const (
+	SessionKey    = attribute.Key("cascade.session.id")
+	ScenarioKey   = attribute.Key("cascade.scenario.id")
+	ClusterKey    = attribute.Key("cascade.cluster")
+	ModeKey       = attribute.Key("cascade.mode")
+	OrderingKey   = attribute.Key("cascade.ordering")
+	CandidatesKey = attribute.Key("cascade.candidates")
+	VictimsKey    = attribute.Key("cascade.victims")
+	SeedKey       = attribute.Key("cascade.seed")
+	TickKey       = attribute.Key("cascade.tick")
+	PodKey        = attribute.Key("k8s.pod.name")
+	NamespaceKey  = attribute.Key("k8s.namespace.name")
+	NodeKey       = attribute.Key("k8s.node.name")
+)
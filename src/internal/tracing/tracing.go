@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/wizenheimer/cascade/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumentation scope for every span emitted by cascade
+const tracerName = "github.com/wizenheimer/cascade"
+
+// Shuts down the tracer provider, flushing pending spans
+type ShutdownFunc func(context.Context) error
+
+// Initializes the global tracer provider using the exporter set via environment variables
+//
+//	TRACES_EXPORTER: one of none, otlp, stdout, file
+//	TRACES_ENDPOINT: OTLP/HTTP endpoint, falls back to OTEL_EXPORTER_OTLP_ENDPOINT
+//	TRACES_FILE:     path the file exporter appends spans to
+func Setup(ctx context.Context, service string) (ShutdownFunc, error) {
+	exporter, closer, err := newExporter(ctx, config.GetEnv("TRACES_EXPORTER", config.TRACES_EXPORTER))
+	if err != nil {
+		return nil, err
+	}
+
+	// Propagate trace context onto outgoing requests regardless of the exporter
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(service),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// Returns the span exporter for the given name, nil incase tracing is disabled
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, io.Closer, error) {
+	switch name {
+	case "", "none":
+		return nil, nil, nil
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if endpoint := config.GetEnv("TRACES_ENDPOINT", ""); endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		return exporter, nil, err
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case "file":
+		file, err := os.OpenFile(config.GetEnv("TRACES_FILE", config.TRACES_FILE), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported traces exporter: %s", name)
+	}
+}
+
+// Returns the tracer used for instrumenting cascade
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Wraps a round tripper, recording a span and injecting the trace context per request
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
+
+// Starts the root span for a chaos session, detached from any caller's trace
+func StartSession(ctx context.Context, sessionID, scenario string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "cascade.session",
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			SessionKey.String(sessionID),
+			ScenarioKey.String(scenario),
+		),
+	)
+}
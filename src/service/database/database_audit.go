@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+
+	"github.com/wizenheimer/cascade/internal/models"
+)
+
+// Upper bound on the number of audit entries returned at once
+const maxAuditEntries = 1000
+
+// CreateAuditEntry appends an entry onto the audit log
+func (c Client) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) (*models.AuditEntry, error) {
+	query := c.DB.WithContext(ctx)
+	if entry.ActorID == "" {
+		// Entries recorded by the system itself have no actor
+		query = query.Omit("actor_id")
+	}
+
+	result := query.Create(entry)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return entry, nil
+}
+
+// ListAuditEntries returns the audit entries matching the filter, latest first
+func (c Client) ListAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	query := c.DB.WithContext(ctx)
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > maxAuditEntries {
+		limit = maxAuditEntries
+	}
+
+	result := query.Order("created_at DESC").Limit(limit).Find(&entries)
+	return entries, result.Error
+}
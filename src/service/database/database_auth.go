@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateAPIKey persists a new API Key
+func (c Client) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	key.ID = uuid.NewString()
+	result := c.DB.WithContext(ctx).Create(key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return nil, &ConflictError{}
+		}
+		return nil, result.Error
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyByHash returns an unrevoked API Key by its hash and marks it as used
+func (c Client) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	result := c.DB.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", hash).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "api key"}
+		}
+		return nil, result.Error
+	}
+
+	now := time.Now()
+	key.LastUsedAt = &now
+	result = c.DB.WithContext(ctx).Model(&key).Update("last_used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeysByUser returns the API Keys issued by the user
+func (c Client) ListAPIKeysByUser(ctx context.Context, userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	result := c.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys)
+	return keys, result.Error
+}
+
+// RevokeAPIKey revokes an API Key issued by the user
+func (c Client) RevokeAPIKey(ctx context.Context, keyID string, userID string) (*models.APIKey, error) {
+	var key models.APIKey
+	result := c.DB.WithContext(ctx).Where("key_id = ? AND user_id = ?", keyID, userID).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "api key", ID: keyID}
+		}
+		return nil, result.Error
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	result = c.DB.WithContext(ctx).Save(&key)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &key, nil
+}
+
+// RevokeToken invalidates a session token until it expires
+func (c Client) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	token := models.RevokedToken{
+		ID:        tokenID,
+		ExpiresAt: expiresAt,
+	}
+
+	// Housekeeping, expired tokens are rejected regardless
+	result := c.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return c.DB.WithContext(ctx).Create(&token).Error
+}
+
+// IsTokenRevoked checks if a session token has been revoked
+func (c Client) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	result := c.DB.WithContext(ctx).Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wizenheimer/cascade/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CreateCluster registers a new cluster
+func (c Client) CreateCluster(ctx context.Context, cluster *models.Cluster) (*models.Cluster, error) {
+	cluster.ID = uuid.NewString()
+	result := c.DB.WithContext(ctx).Create(cluster)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return nil, &ConflictError{}
+		}
+		return nil, result.Error
+	}
+
+	return cluster, nil
+}
+
+// GetClusterByID returns a registered cluster, along with its sealed credentials
+func (c Client) GetClusterByID(ctx context.Context, clusterID string) (*models.Cluster, error) {
+	var cluster models.Cluster
+	result := c.DB.WithContext(ctx).Where("cluster_id = ?", clusterID).First(&cluster)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "cluster", ID: clusterID}
+		}
+		return nil, result.Error
+	}
+	return &cluster, nil
+}
+
+// ListClusters returns the clusters registered by the owning team
+func (c Client) ListClusters(ctx context.Context, teamID string) ([]models.Cluster, error) {
+	var clusters []models.Cluster
+	result := c.DB.WithContext(ctx).Where("team_id = ?", teamID).Order("cluster_name").Find(&clusters)
+	return clusters, result.Error
+}
+
+// UpdateCluster updates a registered cluster, unset fields are left untouched
+func (c Client) UpdateCluster(ctx context.Context, clusterID string, updatedCluster *models.Cluster) (*models.Cluster, error) {
+	cluster, err := c.GetClusterByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if updatedCluster.Name != "" {
+		cluster.Name = updatedCluster.Name
+	}
+	if updatedCluster.Server != "" {
+		cluster.Server = updatedCluster.Server
+	}
+	if len(updatedCluster.Credentials) > 0 {
+		cluster.Credentials = updatedCluster.Credentials
+	}
+
+	result := c.DB.WithContext(ctx).Save(cluster)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return cluster, nil
+}
+
+// DeleteCluster removes a registered cluster
+func (c Client) DeleteCluster(ctx context.Context, clusterID string) (*models.Cluster, error) {
+	cluster, err := c.GetClusterByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := c.DB.WithContext(ctx).Delete(cluster)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return cluster, nil
+}
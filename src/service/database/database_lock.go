@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AcquireAdvisoryLock attempts to take a session level advisory lock without blocking,
+// the lock is held by the returned connection until released
+func (c Client) AcquireAdvisoryLock(ctx context.Context, key int64) (*sql.Conn, bool, error) {
+	db, err := c.DB.DB()
+	if err != nil {
+		return nil, false, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// SQLite databases are local to a single replica, which always holds the lock
+	if c.sqlite() {
+		return conn, true, nil
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return conn, true, nil
+}
+
+// ReleaseAdvisoryLock releases the advisory lock, and hands the connection back to the pool
+func (c Client) ReleaseAdvisoryLock(ctx context.Context, conn *sql.Conn, key int64) error {
+	defer conn.Close()
+	if c.sqlite() {
+		return nil
+	}
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+	return err
+}
@@ -42,6 +42,30 @@ func (c Client) UpdateScenario(ctx context.Context, scenarioID string, updatedSc
 
 	// Update fields from updatedScenario
 	newScenario.Description = updatedScenario.Description
+	newScenario.Namespaces = updatedScenario.Namespaces
+	newScenario.IncludedPodNames = updatedScenario.IncludedPodNames
+	newScenario.IncludedNodeNames = updatedScenario.IncludedNodeNames
+	newScenario.ExcludedPodNames = updatedScenario.ExcludedPodNames
+	newScenario.Interval = updatedScenario.Interval
+	newScenario.Grace = updatedScenario.Grace
+	newScenario.Mode = updatedScenario.Mode
+	newScenario.Ordering = updatedScenario.Ordering
+	newScenario.Ratio = updatedScenario.Ratio
+	newScenario.Distribution = updatedScenario.Distribution
+	newScenario.MinInterval = updatedScenario.MinInterval
+	newScenario.MaxInterval = updatedScenario.MaxInterval
+	newScenario.IntervalJitter = updatedScenario.IntervalJitter
+	newScenario.InitialDelay = updatedScenario.InitialDelay
+	newScenario.Seed = updatedScenario.Seed
+	newScenario.Count = updatedScenario.Count
+	newScenario.MinVictims = updatedScenario.MinVictims
+	newScenario.MaxVictims = updatedScenario.MaxVictims
+	newScenario.Rounding = updatedScenario.Rounding
+	newScenario.Concurrency = updatedScenario.Concurrency
+	newScenario.RateLimit = updatedScenario.RateLimit
+	newScenario.Stagger = updatedScenario.Stagger
+	newScenario.Jitter = updatedScenario.Jitter
+	newScenario.ClusterID = updatedScenario.ClusterID
 	if updatedScenario.TeamID != "" {
 		newScenario.TeamID = updatedScenario.TeamID
 	}
@@ -96,14 +120,17 @@ func (c Client) ListScenarioVersion(ctx context.Context, scenarioID string) ([]m
 // ListScenarioByTeamID returns a list of scenarios inside a Team
 func (c Client) ListScenariosByTeamID(ctx context.Context, teamID string) ([]models.Scenario, error) {
 	var scenarios []models.Scenario
-	result := c.DB.Select("scenario_id").Where("team_id = ?", teamID).Find(&scenarios)
+	result := c.DB.Select("scenario_id", "version", "description", "created_at").Where("team_id = ?", teamID).Find(&scenarios)
 	return scenarios, result.Error
 }
 
 // GetScenarioByID returns a scenario by its ID
 func (c Client) GetScenarioByID(ctx context.Context, scenarioID string) ([]models.Scenario, error) {
 	var scenarios []models.Scenario
-	result := c.DB.Select("scenario_name", "version", "description", "created_at").Where("scenario_id = ?", scenarioID).Find(&scenarios).Order("version DESC")
+	result := c.DB.Select("scenario_id", "version", "description", "team_id", "created_at").
+		Where("scenario_id = ?", scenarioID).
+		Order("version DESC").
+		Find(&scenarios)
 	return scenarios, result.Error
 }
 
@@ -111,8 +138,7 @@ func (c Client) GetScenarioByID(ctx context.Context, scenarioID string) ([]model
 func (c Client) GetScenarioByIDByVersion(ctx context.Context, scenarioID string, version int) (models.Scenario, error) {
 	var scenario models.Scenario
 
-	result := c.DB.Select("scenario_name", "version", "description", "created_at").
-		Where("scenario_id = ?", scenarioID).
+	result := c.DB.Where("scenario_id = ?", scenarioID).
 		Where("version = ?", version).
 		First(&scenario)
 	return scenario, result.Error
@@ -2,12 +2,16 @@ package database
 
 import (
 	"context"
-	"github.com/wizenheimer/cascade/internal/models"
+	"errors"
 	"time"
+
+	"github.com/wizenheimer/cascade/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateSession creates a new session for a given scenario
-func (c Client) CreateSession(ctx context.Context, scenarioID string, version int) (*models.Session, error) {
+func (c Client) CreateSession(ctx context.Context, scenarioID string, version int, userID string, clusterID string, seed int64) (*models.Session, error) {
 	var scenario models.Scenario
 	if err := c.DB.Where("scenario_id = ?", scenarioID).Order("version DESC").First(&scenario).Error; err != nil {
 		return nil, err
@@ -17,11 +21,24 @@ func (c Client) CreateSession(ctx context.Context, scenarioID string, version in
 		version = scenario.Version
 	}
 
+	// Scenarios of deactivated teams are not allowed to run
+	team, err := c.GetTeamByID(ctx, scenario.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if !team.IsActive {
+		return nil, &InactiveError{Entity: "team", ID: team.ID}
+	}
+
 	session := &models.Session{
-		ScenarioID: scenarioID,
-		Version:    version,
-		StartTime:  time.Now(),
-		Status:     "queued",
+		ScenarioID:  scenarioID,
+		UserID:      userID,
+		ClusterID:   clusterID,
+		Seed:        seed,
+		Version:     version,
+		StartTime:   time.Now(),
+		HeartbeatAt: time.Now(),
+		Status:      "queued",
 	}
 
 	result := c.DB.Create(session)
@@ -32,14 +49,16 @@ func (c Client) CreateSession(ctx context.Context, scenarioID string, version in
 	return session, nil
 }
 
-// StartSession marks session as in-progress
-func (c Client) StartSession(ctx context.Context, sessionID string) (*models.Session, error) {
+// StartSession marks session as in-progress, owned by the given replica
+func (c Client) StartSession(ctx context.Context, sessionID string, owner string) (*models.Session, error) {
 	var session models.Session
 	if err := c.DB.First(&session, sessionID).Error; err != nil {
 		return nil, err
 	}
 
 	session.StartTime = time.Now()
+	session.HeartbeatAt = session.StartTime
+	session.Owner = owner
 	session.Status = "running"
 
 	result := c.DB.Save(&session)
@@ -50,6 +69,50 @@ func (c Client) StartSession(ctx context.Context, sessionID string) (*models.Ses
 	return &session, nil
 }
 
+// HeartbeatSessions marks every running session of the replica as alive
+func (c Client) HeartbeatSessions(ctx context.Context, owner string) (int64, error) {
+	result := c.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("owner = ? AND status = ?", owner, "running").
+		Update("heartbeat_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// FailStaleSessions marks sessions whose owner stopped heartbeating before the given time as failed,
+// clusters yet to wrap up are marked as cancelled
+func (c Client) FailStaleSessions(ctx context.Context, before time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ?", []string{"queued", "running"}).
+			Where("COALESCE(heartbeat_at, start_time) < ?", before).
+			Find(&sessions).Error
+		if err != nil || len(sessions) == 0 {
+			return err
+		}
+
+		ids := make([]int, len(sessions))
+		for i, session := range sessions {
+			ids[i] = session.ID
+		}
+
+		now := time.Now()
+		err = tx.Model(&models.Session{}).Where("session_id IN ?", ids).
+			Updates(map[string]interface{}{"status": "failed", "end_time": now}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.SessionCluster{}).
+			Where("session_id IN ? AND status IN ?", ids, []string{"pending", "running"}).
+			Updates(map[string]interface{}{"status": "cancelled", "end_time": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
 // GracefullyEndSession marks session as a graceful exit
 func (c Client) GracefullyEndSession(ctx context.Context, sessionID string) (*models.Session, error) {
 	var session models.Session
@@ -86,6 +149,58 @@ func (c Client) TerminateSession(ctx context.Context, sessionID string) (*models
 	return &session, nil
 }
 
+// GetSessionByID returns a session
+func (c Client) GetSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
+	var session models.Session
+	result := c.DB.WithContext(ctx).Where("session_id = ?", sessionID).First(&session)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "session", ID: sessionID}
+		}
+		return nil, result.Error
+	}
+	return &session, nil
+}
+
+// CreateSessionClusters records the clusters targeted by a session, all of them pending
+func (c Client) CreateSessionClusters(ctx context.Context, sessionID int, strategy string, clusters []string) ([]models.SessionCluster, error) {
+	rows := make([]models.SessionCluster, len(clusters))
+	for i, cluster := range clusters {
+		rows[i] = models.SessionCluster{
+			SessionID: sessionID,
+			Cluster:   cluster,
+			Position:  i,
+			Status:    "pending",
+		}
+	}
+
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Session{}).Where("session_id = ?", sessionID).Update("fanout", strategy).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// UpdateSessionCluster records the latest status of a cluster targeted by a session
+func (c Client) UpdateSessionCluster(ctx context.Context, cluster *models.SessionCluster) error {
+	return c.DB.WithContext(ctx).Model(cluster).
+		Select("status", "rounds", "victims", "failures", "error", "start_time", "end_time").
+		Updates(cluster).Error
+}
+
+// ListSessionClusters returns the clusters targeted by a session, in the order they were targeted
+func (c Client) ListSessionClusters(ctx context.Context, sessionID int) ([]models.SessionCluster, error) {
+	var clusters []models.SessionCluster
+	result := c.DB.WithContext(ctx).Where("session_id = ?", sessionID).Order("position").Find(&clusters)
+	return clusters, result.Error
+}
+
 func (c Client) ListSessionByScenarioID(ctx context.Context, scenarioID string, version int) ([]models.Session, error) {
 	var sessions []models.Session
 	query := c.DB.Where("scenario_id = ?", scenarioID)
@@ -3,13 +3,18 @@ package database
 import (
 	"context"
 	"errors"
+
 	"github.com/wizenheimer/cascade/internal/models"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Join table holding team memberships
+const userTeamTable = "user_team"
+
 // CreateTeam creates a new team, duh
+// The creator becomes the owner of the team
 func (c Client) CreateTeam(ctx context.Context, name string, description string, creator *models.User) (*models.Team, error) {
 	if creator == nil {
 		return nil, &ConflictError{}
@@ -22,17 +27,23 @@ func (c Client) CreateTeam(ctx context.Context, name string, description string,
 		IsActive:    true,
 	}
 
-	// Create the team
-	result := c.DB.WithContext(ctx).Create(&team)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
-			return nil, &ConflictError{}
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Create the team
+		if err := tx.Create(team).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return &ConflictError{}
+			}
+			return err
 		}
-		return nil, result.Error
-	}
 
-	// Add the creator to the team
-	if _, err := c.AddUserToTeam(ctx, creator, team); err != nil {
+		// Add the creator to the team
+		return tx.Table(c.userTeamTable()).Create(&models.UserTeam{
+			UserID: creator.ID,
+			TeamID: team.ID,
+			Role:   models.MembershipOwner,
+		}).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -43,53 +54,93 @@ func (c Client) GetTeamByID(ctx context.Context, teamID string) (*models.Team, e
 	var team models.Team
 
 	// Fetch the team by ID
-	result := c.DB.WithContext(ctx).Where("id = ?", teamID).First(&team)
+	result := c.DB.WithContext(ctx).Where("team_id = ?", teamID).First(&team)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, &NotFoundError{}
+			return nil, &NotFoundError{Entity: "team", ID: teamID}
 		}
 		return nil, result.Error
 	}
 	return &team, nil
 }
 
-func (c Client) AddUserToTeam(ctx context.Context, user *models.User, team *models.Team) (*models.User, error) {
-	user.Teams = append(user.Teams, *team)
-	if _, err := c.UpdateUser(ctx, user.Email, user); err != nil {
-		return nil, err
+// AddUserToTeam adds the user to the team, or updates their role incase they're already a member
+func (c Client) AddUserToTeam(ctx context.Context, user *models.User, team *models.Team, role string) (*models.User, error) {
+	membership := models.UserTeam{
+		UserID: user.ID,
+		TeamID: team.ID,
+		Role:   role,
+	}
+
+	result := c.DB.WithContext(ctx).Table(c.userTeamTable()).Save(&membership)
+	if result.Error != nil {
+		return nil, result.Error
 	}
 
 	return user, nil
 }
 
 func (c Client) RemoveUserFromTeam(ctx context.Context, user *models.User, team *models.Team) (*models.User, error) {
-	// Remove the team from the user
-	for i, t := range user.Teams {
-		if t.ID == team.ID {
-			user.Teams = append(user.Teams[:i], user.Teams[i+1:]...)
-			break
+	result := c.DB.WithContext(ctx).
+		Table(c.userTeamTable()).
+		Where("user_id = ? AND team_id = ?", user.ID, team.ID).
+		Delete(&models.UserTeam{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, &NotFoundError{Entity: "membership", ID: user.ID}
+	}
+
+	return user, nil
+}
+
+// GetMembership returns the membership of the user within the team
+func (c Client) GetMembership(ctx context.Context, teamID string, userID string) (*models.UserTeam, error) {
+	var membership models.UserTeam
+	result := c.DB.WithContext(ctx).
+		Table(c.userTeamTable()).
+		Where("user_id = ? AND team_id = ?", userID, teamID).
+		First(&membership)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "membership", ID: userID}
 		}
+		return nil, result.Error
 	}
+	return &membership, nil
+}
 
-	// Update the user
-	if _, err := c.UpdateUser(ctx, user.Email, user); err != nil {
+func (c Client) ListUsersByTeam(ctx context.Context, teamID string) ([]models.User, error) {
+	members, err := c.ListMembersByTeam(ctx, teamID)
+	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	users := make([]models.User, 0, len(members))
+	for _, member := range members {
+		users = append(users, member.User)
+	}
+	return users, nil
 }
 
-func (c Client) ListUsersByTeam(ctx context.Context, teamID string) ([]models.User, error) {
-	var users []models.User
+// ListMembersByTeam returns the users of the team along with their role within it
+func (c Client) ListMembersByTeam(ctx context.Context, teamID string) ([]models.TeamMember, error) {
+	var members []models.TeamMember
+	users := c.DB.NamingStrategy.TableName("User")
+	memberships := c.userTeamTable()
+
 	result := c.DB.
 		WithContext(ctx).
-		Joins("JOIN cascade.user_team ON cascade.users.user_id = cascade.user_team.user_id").
-		Where("cascade.user_team.team_id = ?", teamID).
-		Find(&users)
+		Table(users).
+		Select(users+".*, "+memberships+".role AS membership").
+		Joins("JOIN "+memberships+" ON "+users+".user_id = "+memberships+".user_id").
+		Where(memberships+".team_id = ?", teamID).
+		Scan(&members)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	return users, nil
+	return members, nil
 }
 
 func (c Client) UpdateTeambyTeamID(ctx context.Context, teamID string, updatedTeam *models.Team) (*models.Team, error) {
@@ -142,3 +193,23 @@ func (c Client) DeactivateTeam(ctx context.Context, teamID string) (*models.Team
 
 	return team, nil
 }
+
+func (c Client) ActivateTeam(ctx context.Context, teamID string) (*models.Team, error) {
+	team, err := c.GetTeamByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	team.IsActive = true
+	result := c.DB.WithContext(ctx).Save(&team)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return team, nil
+}
+
+// Returns the name of the join table holding team memberships
+func (c Client) userTeamTable() string {
+	return c.DB.NamingStrategy.JoinTableName(userTeamTable)
+}
@@ -30,7 +30,19 @@ func (c Client) GetUserByEmail(ctx context.Context, email string) (*models.User,
 	result := c.DB.WithContext(ctx).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, &NotFoundError{}
+			return nil, &NotFoundError{Entity: "user", ID: email}
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+func (c Client) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	var user models.User
+	result := c.DB.WithContext(ctx).Where("user_id = ?", userID).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Entity: "user", ID: userID}
 		}
 		return nil, result.Error
 	}
@@ -49,6 +61,9 @@ func (c Client) UpdateUser(ctx context.Context, email string, updatedUser *model
 	if updatedUser.Role != "" {
 		user.Role = updatedUser.Role
 	}
+	if updatedUser.Password != "" {
+		user.Password = updatedUser.Password
+	}
 	result := c.DB.WithContext(ctx).Save(&user)
 	if result.Error != nil {
 		return nil, result.Error
@@ -16,3 +16,12 @@ type NotFoundError struct {
 func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("unable to find %s with id %s", e.Entity, e.ID)
 }
+
+type InactiveError struct {
+	Entity string
+	ID     string
+}
+
+func (e *InactiveError) Error() string {
+	return fmt.Sprintf("%s with id %s has been deactivated", e.Entity, e.ID)
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/models"
+	"gorm.io/gorm"
+)
+
+// Migrations of every dialect, named <version>_<name>.up.sql along with <version>_<name>.down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Key of the advisory lock serializing replicas migrating at once
+const migrationLockKey int64 = 0x6d696772617465 // "migrate"
+
+// Version of the baseline migration, the schema the former schema/init.sql created
+const baselineVersion = 1
+
+// Migration of the schema, applied in order of its version
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// State of a migration within the database, either of them is unset if the binary and the database disagree
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Whether the migration is known to the binary, migrations applied by a newer binary aren't
+	Known bool
+}
+
+// Returns the migrations of the dialect of the client, ordered by version
+func (c Client) migrations() ([]Migration, error) {
+	dir := path.Join("migrations", c.DB.Dialector.Name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s databases: %w", c.DB.Dialector.Name(), err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		version, label, found := strings.Cut(name, "_")
+		number, err := strconv.Atoi(version)
+		if !ok || !found || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("malformed migration %s, expected <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+
+		data, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, exists := byVersion[number]
+		if !exists {
+			m = &Migration{Version: number, Name: label}
+			byVersion[number] = m
+		}
+		if direction == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s lacks either of its up or down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Creates the table recording applied migrations, along with the schema holding it
+func (c Client) createMigrationTable(ctx context.Context) error {
+	db := c.DB.WithContext(ctx)
+	if !c.sqlite() {
+		if err := db.Exec("CREATE SCHEMA IF NOT EXISTS cascade").Error; err != nil {
+			return err
+		}
+	}
+	table := c.DB.NamingStrategy.TableName("SchemaMigration")
+	return db.Exec("CREATE TABLE IF NOT EXISTS " + table + " (" +
+		"version INT PRIMARY KEY, " +
+		"name TEXT NOT NULL, " +
+		"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)").Error
+}
+
+// Applies or reverts the migration along with its record, within a single transaction.
+// Reports whether it did, replicas racing each other skip the migrations applied in the meantime
+func (c Client) runMigration(ctx context.Context, m Migration, up bool) (bool, error) {
+	ran := false
+	err := c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !c.sqlite() {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		var applied int64
+		if err := tx.Model(&models.SchemaMigration{}).Where("version = ?", m.Version).Count(&applied).Error; err != nil {
+			return err
+		}
+		if up == (applied > 0) {
+			return nil
+		}
+
+		script := m.down
+		if up {
+			script = m.up
+		}
+		if err := tx.Exec(script).Error; err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		ran = true
+
+		if !up {
+			return tx.Where("version = ?", m.Version).Delete(&models.SchemaMigration{}).Error
+		}
+		return tx.Create(&models.SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+	return ran, err
+}
+
+// Records the baseline as applied onto PostgreSQL databases created by the former schema/init.sql,
+// which predates migrations. Migrations past the baseline upgrade them in place
+func (c Client) adoptBaseline(ctx context.Context, migrations []Migration) error {
+	if c.sqlite() || len(migrations) == 0 || migrations[0].Version != baselineVersion {
+		return nil
+	}
+	baseline := migrations[0]
+
+	return c.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+
+		var recorded int64
+		if err := tx.Model(&models.SchemaMigration{}).Count(&recorded).Error; err != nil {
+			return err
+		}
+		if recorded > 0 {
+			return nil
+		}
+
+		// Databases the migrations never touched only hold the baseline if init.sql created them
+		var exists bool
+		if err := tx.Raw("SELECT to_regclass('cascade.users') IS NOT NULL").Scan(&exists).Error; err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		return tx.Create(&models.SchemaMigration{Version: baseline.Version, Name: baseline.Name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// MigrateUp applies pending migrations in order, every one of them unless steps is positive
+func (c Client) MigrateUp(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := c.migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.createMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.adoptBaseline(ctx, migrations); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if steps > 0 && len(applied) == steps {
+			break
+		}
+		ran, err := c.runMigration(ctx, m, true)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts applied migrations latest first, every one of them unless steps is positive
+func (c Client) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	migrations, err := c.migrations()
+	if err != nil {
+		return nil, err
+	}
+	states, err := c.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]Migration{}
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+
+	var reverted []Migration
+	for i := len(states) - 1; i >= 0; i-- {
+		state := states[i]
+		if state.AppliedAt == nil {
+			continue
+		}
+		if steps > 0 && len(reverted) == steps {
+			break
+		}
+		if !state.Known {
+			return reverted, fmt.Errorf("migration %04d_%s was applied by a newer release, revert it using that release", state.Version, state.Name)
+		}
+
+		m := byVersion[state.Version]
+		ran, err := c.runMigration(ctx, m, false)
+		if err != nil {
+			return reverted, err
+		}
+		if ran {
+			reverted = append(reverted, m)
+		}
+	}
+	return reverted, nil
+}
+
+// MigrationStatus lists out the known migrations along with the applied ones, ordered by version
+func (c Client) MigrationStatus(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := c.migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.createMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var records []models.SchemaMigration
+	if err := c.DB.WithContext(ctx).Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*MigrationState{}
+	for _, m := range migrations {
+		byVersion[m.Version] = &MigrationState{Version: m.Version, Name: m.Name, Known: true}
+	}
+	for _, record := range records {
+		state, known := byVersion[record.Version]
+		if !known {
+			state = &MigrationState{Version: record.Version, Name: record.Name}
+			byVersion[record.Version] = state
+		}
+		appliedAt := record.AppliedAt
+		state.AppliedAt = &appliedAt
+	}
+
+	states := make([]MigrationState, 0, len(byVersion))
+	for _, state := range byVersion {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS cascade.sessions;
+DROP TABLE IF EXISTS cascade.scenarios;
+DROP TABLE IF EXISTS cascade.user_team;
+DROP TABLE IF EXISTS cascade.users;
+DROP TABLE IF EXISTS cascade.team;
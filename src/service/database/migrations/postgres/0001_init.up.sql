@@ -1,3 +1,4 @@
+-- Baseline schema, as created by the former schema/init.sql. Databases created by it adopt this migration as applied
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE SCHEMA IF NOT EXISTS cascade;
 -- Create Team and User Management related relations
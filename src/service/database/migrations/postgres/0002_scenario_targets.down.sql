@@ -0,0 +1,10 @@
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS namespaces,
+    DROP COLUMN IF EXISTS "includedPodNames",
+    DROP COLUMN IF EXISTS "includedNodeNames",
+    DROP COLUMN IF EXISTS "excludedPodNames",
+    DROP COLUMN IF EXISTS "interval",
+    DROP COLUMN IF EXISTS grace,
+    DROP COLUMN IF EXISTS mode,
+    DROP COLUMN IF EXISTS ordering,
+    DROP COLUMN IF EXISTS ratio;
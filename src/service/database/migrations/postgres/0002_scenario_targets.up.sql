@@ -0,0 +1,11 @@
+-- Columns written by models.Scenario, missing from the baseline schema
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS namespaces TEXT,
+    ADD COLUMN IF NOT EXISTS "includedPodNames" TEXT,
+    ADD COLUMN IF NOT EXISTS "includedNodeNames" TEXT,
+    ADD COLUMN IF NOT EXISTS "excludedPodNames" TEXT,
+    ADD COLUMN IF NOT EXISTS "interval" TEXT,
+    ADD COLUMN IF NOT EXISTS grace TEXT,
+    ADD COLUMN IF NOT EXISTS mode TEXT,
+    ADD COLUMN IF NOT EXISTS ordering TEXT,
+    ADD COLUMN IF NOT EXISTS ratio DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cascade.audit_entries;
+DROP FUNCTION IF EXISTS cascade.reject_audit_mutation();
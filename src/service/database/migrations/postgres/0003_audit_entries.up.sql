@@ -0,0 +1,23 @@
+-- Create Audit related relations
+CREATE TABLE IF NOT EXISTS cascade.audit_entries (
+    audit_id SERIAL PRIMARY KEY,
+    actor_id UUID,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id TEXT,
+    diff TEXT,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Audit log is append-only, reject any attempt at rewriting history
+CREATE OR REPLACE FUNCTION cascade.reject_audit_mutation() RETURNS TRIGGER AS $$ BEGIN RAISE EXCEPTION 'cascade.audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_entries_append_only ON cascade.audit_entries;
+CREATE TRIGGER audit_entries_append_only BEFORE
+UPDATE
+    OR DELETE
+    OR TRUNCATE ON cascade.audit_entries FOR EACH STATEMENT EXECUTE FUNCTION cascade.reject_audit_mutation();
+CREATE INDEX IF NOT EXISTS idx_audit_actor_id ON cascade.audit_entries(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_target ON cascade.audit_entries(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON cascade.audit_entries(created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS cascade.revoked_tokens;
+DROP TABLE IF EXISTS cascade.api_keys;
+ALTER TABLE cascade.users
+    DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,22 @@
+-- Passwords are hashed, users created before sign ups lack one until it's set
+ALTER TABLE cascade.users
+    ADD COLUMN IF NOT EXISTS password_hash TEXT;
+-- Create Authentication related relations
+CREATE TABLE IF NOT EXISTS cascade.api_keys (
+    key_id UUID PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    user_id UUID NOT NULL,
+    team_id UUID,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES cascade.users(user_id),
+    FOREIGN KEY (team_id) REFERENCES cascade.team(team_id)
+);
+CREATE TABLE IF NOT EXISTS cascade.revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON cascade.api_keys(user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE cascade.user_team
+    DROP COLUMN IF EXISTS role;
+ALTER TABLE IF EXISTS cascade.teams RENAME TO team;
@@ -0,0 +1,5 @@
+-- Tables are named in the plural, foreign keys referencing the table follow it along
+ALTER TABLE IF EXISTS cascade.team RENAME TO teams;
+-- Members either own the team or belong to it
+ALTER TABLE cascade.user_team
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member'));
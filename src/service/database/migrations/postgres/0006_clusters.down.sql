@@ -0,0 +1,5 @@
+ALTER TABLE cascade.sessions
+    DROP COLUMN IF EXISTS cluster_id;
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS cluster_id;
+DROP TABLE IF EXISTS cascade.clusters;
@@ -0,0 +1,18 @@
+-- Create Cluster Registry related relations, credentials are sealed with CLUSTER_SECRET_KEY
+CREATE TABLE IF NOT EXISTS cascade.clusters (
+    cluster_id UUID PRIMARY KEY DEFAULT (uuid_generate_v4()),
+    cluster_name VARCHAR(100) NOT NULL,
+    server TEXT NOT NULL,
+    credentials BYTEA NOT NULL,
+    team_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (team_id, cluster_name),
+    FOREIGN KEY (team_id) REFERENCES cascade.teams(team_id)
+);
+CREATE INDEX IF NOT EXISTS idx_cluster_team_id ON cascade.clusters(team_id);
+-- Scenarios and sessions reference the registered cluster they target
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS cluster_id TEXT;
+ALTER TABLE cascade.sessions
+    ADD COLUMN IF NOT EXISTS cluster_id TEXT;
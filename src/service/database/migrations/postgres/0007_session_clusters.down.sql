@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS cascade.session_clusters;
+ALTER TABLE cascade.sessions
+    DROP COLUMN IF EXISTS fanout;
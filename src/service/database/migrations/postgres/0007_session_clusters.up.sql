@@ -0,0 +1,19 @@
+-- Sessions fan out across clusters, recording the status of every one of them
+ALTER TABLE cascade.sessions
+    ADD COLUMN IF NOT EXISTS fanout VARCHAR(20);
+CREATE TABLE IF NOT EXISTS cascade.session_clusters (
+    session_id INT NOT NULL,
+    cluster TEXT NOT NULL,
+    position INT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (
+        status IN ('pending', 'running', 'completed', 'failed', 'cancelled')
+    ),
+    rounds INT NOT NULL DEFAULT 0,
+    victims INT NOT NULL DEFAULT 0,
+    failures INT NOT NULL DEFAULT 0,
+    error TEXT,
+    start_time TIMESTAMP,
+    end_time TIMESTAMP,
+    PRIMARY KEY (session_id, cluster),
+    FOREIGN KEY (session_id) REFERENCES cascade.sessions(session_id)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS cascade.idx_session_status_heartbeat;
+ALTER TABLE cascade.sessions
+    DROP COLUMN IF EXISTS owner,
+    DROP COLUMN IF EXISTS heartbeat_at;
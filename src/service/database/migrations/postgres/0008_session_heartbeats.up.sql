@@ -0,0 +1,5 @@
+-- Replicas own the sessions they run and heartbeat them, the leader fails sessions of dead owners
+ALTER TABLE cascade.sessions
+    ADD COLUMN IF NOT EXISTS owner TEXT,
+    ADD COLUMN IF NOT EXISTS heartbeat_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_session_status_heartbeat ON cascade.sessions(status, heartbeat_at);
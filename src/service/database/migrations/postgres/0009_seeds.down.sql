@@ -0,0 +1,4 @@
+ALTER TABLE cascade.sessions
+    DROP COLUMN IF EXISTS seed;
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS seed;
@@ -0,0 +1,5 @@
+-- Seeds of victim selection, set on scenarios and recorded on every session for replays
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS seed TEXT;
+ALTER TABLE cascade.sessions
+    ADD COLUMN IF NOT EXISTS seed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS count,
+    DROP COLUMN IF EXISTS min_victims,
+    DROP COLUMN IF EXISTS max_victims,
+    DROP COLUMN IF EXISTS rounding;
@@ -0,0 +1,6 @@
+-- Victim counts, bounds and rounding alongside the ratio
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS min_victims INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_victims INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rounding TEXT;
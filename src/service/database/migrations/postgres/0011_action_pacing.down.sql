@@ -0,0 +1,5 @@
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS concurrency,
+    DROP COLUMN IF EXISTS rate_limit,
+    DROP COLUMN IF EXISTS stagger,
+    DROP COLUMN IF EXISTS jitter;
@@ -0,0 +1,6 @@
+-- Concurrency and pacing of the actions taken upon victims
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS concurrency INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS stagger TEXT,
+    ADD COLUMN IF NOT EXISTS jitter TEXT;
@@ -0,0 +1,6 @@
+ALTER TABLE cascade.scenarios
+    DROP COLUMN IF EXISTS distribution,
+    DROP COLUMN IF EXISTS min_interval,
+    DROP COLUMN IF EXISTS max_interval,
+    DROP COLUMN IF EXISTS interval_jitter,
+    DROP COLUMN IF EXISTS initial_delay;
@@ -0,0 +1,7 @@
+-- Distribution, bounds and jitter of the intervals between ticks
+ALTER TABLE cascade.scenarios
+    ADD COLUMN IF NOT EXISTS distribution TEXT,
+    ADD COLUMN IF NOT EXISTS min_interval TEXT,
+    ADD COLUMN IF NOT EXISTS max_interval TEXT,
+    ADD COLUMN IF NOT EXISTS interval_jitter TEXT,
+    ADD COLUMN IF NOT EXISTS initial_delay TEXT;
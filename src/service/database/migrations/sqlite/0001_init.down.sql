@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS audit_entries;
+DROP TABLE IF EXISTS session_clusters;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS scenarios;
+DROP TABLE IF EXISTS clusters;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS user_team;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,159 @@
+-- Initial schema of SQLite databases, which postdate schema/init.sql and start from the schema the PostgreSQL migrations reach
+-- Create Team and User Management related relations
+CREATE TABLE IF NOT EXISTS teams (
+    team_id TEXT PRIMARY KEY,
+    team_name VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS users (
+    user_id TEXT PRIMARY KEY,
+    email VARCHAR(100) NOT NULL UNIQUE,
+    password_hash TEXT,
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin', 'manager')),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS user_team (
+    user_id TEXT NOT NULL,
+    team_id TEXT NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+    PRIMARY KEY (user_id, team_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id),
+    FOREIGN KEY (team_id) REFERENCES teams(team_id)
+);
+-- Create Authentication related relations
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id TEXT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL,
+    team_id TEXT,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id),
+    FOREIGN KEY (team_id) REFERENCES teams(team_id)
+);
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    token_id TEXT PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
+-- Create Cluster Registry related relations, credentials are sealed with CLUSTER_SECRET_KEY
+CREATE TABLE IF NOT EXISTS clusters (
+    cluster_id TEXT PRIMARY KEY,
+    cluster_name VARCHAR(100) NOT NULL,
+    server TEXT NOT NULL,
+    credentials BLOB NOT NULL,
+    team_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (team_id, cluster_name),
+    FOREIGN KEY (team_id) REFERENCES teams(team_id)
+);
+-- Create Chaos Engineering related relations
+CREATE TABLE IF NOT EXISTS scenarios (
+    scenario_id TEXT NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    description TEXT,
+    namespaces TEXT,
+    includedPodNames TEXT,
+    includedNodeNames TEXT,
+    excludedPodNames TEXT,
+    interval TEXT,
+    grace TEXT,
+    mode TEXT,
+    ordering TEXT,
+    ratio TEXT,
+    team_id TEXT NOT NULL,
+    cluster_id TEXT,
+    seed TEXT,
+    count INT NOT NULL DEFAULT 0,
+    min_victims INT NOT NULL DEFAULT 0,
+    max_victims INT NOT NULL DEFAULT 0,
+    rounding TEXT,
+    concurrency INT NOT NULL DEFAULT 0,
+    rate_limit DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stagger TEXT,
+    jitter TEXT,
+    distribution TEXT,
+    min_interval TEXT,
+    max_interval TEXT,
+    interval_jitter TEXT,
+    initial_delay TEXT,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (scenario_id, version),
+    FOREIGN KEY (team_id) REFERENCES teams(team_id)
+);
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scenario_id TEXT NOT NULL,
+    version INT NOT NULL,
+    user_id TEXT NOT NULL,
+    cluster_id TEXT,
+    fanout VARCHAR(20),
+    seed BIGINT NOT NULL DEFAULT 0,
+    owner TEXT,
+    heartbeat_at TIMESTAMP,
+    start_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    end_time TIMESTAMP,
+    status VARCHAR(20) NOT NULL CHECK (
+        status IN ('queued', 'running', 'completed', 'failed')
+    ),
+    FOREIGN KEY (scenario_id, version) REFERENCES scenarios(scenario_id, version),
+    FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+CREATE TABLE IF NOT EXISTS session_clusters (
+    session_id INT NOT NULL,
+    cluster TEXT NOT NULL,
+    position INT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (
+        status IN ('pending', 'running', 'completed', 'failed', 'cancelled')
+    ),
+    rounds INT NOT NULL DEFAULT 0,
+    victims INT NOT NULL DEFAULT 0,
+    failures INT NOT NULL DEFAULT 0,
+    error TEXT,
+    start_time TIMESTAMP,
+    end_time TIMESTAMP,
+    PRIMARY KEY (session_id, cluster),
+    FOREIGN KEY (session_id) REFERENCES sessions(session_id)
+);
+-- Create Audit related relations
+CREATE TABLE IF NOT EXISTS audit_entries (
+    audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id TEXT,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id TEXT,
+    diff TEXT,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- Audit log is append-only, reject any attempt at rewriting history
+CREATE TRIGGER IF NOT EXISTS audit_entries_append_only_update BEFORE UPDATE ON audit_entries
+BEGIN
+    SELECT RAISE(ABORT, 'audit_entries is append-only');
+END;
+CREATE TRIGGER IF NOT EXISTS audit_entries_append_only_delete BEFORE DELETE ON audit_entries
+BEGIN
+    SELECT RAISE(ABORT, 'audit_entries is append-only');
+END;
+-- Add indexes
+CREATE INDEX IF NOT EXISTS idx_scenario_team_id ON scenarios(team_id);
+CREATE INDEX IF NOT EXISTS idx_session_scenario_id ON sessions(scenario_id);
+CREATE INDEX IF NOT EXISTS idx_session_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_session_status_heartbeat ON sessions(status, heartbeat_at);
+CREATE INDEX IF NOT EXISTS idx_user_team_user_id ON user_team(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_team_team_id ON user_team(team_id);
+CREATE INDEX IF NOT EXISTS idx_audit_actor_id ON audit_entries(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_target ON audit_entries(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_entries(created_at);
+CREATE INDEX IF NOT EXISTS idx_cluster_team_id ON clusters(team_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
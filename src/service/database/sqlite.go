@@ -0,0 +1,20 @@
+package database
+
+import "strings"
+
+// Prefix of data source names pointing to a SQLite database
+const SQLitePrefix = "sqlite://"
+
+// Enforces foreign keys as PostgreSQL does, and lets concurrent writers wait on each other
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
+
+// Reports whether the client is backed by a SQLite database
+func (c Client) sqlite() bool {
+	return c.DB.Dialector.Name() == "sqlite"
+}
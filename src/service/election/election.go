@@ -0,0 +1,43 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// Invoked as leadership is gained and lost
+type Callbacks struct {
+	// Runs scheduler duties, the context is cancelled once leadership is lost
+	OnStartedLeading func(ctx context.Context)
+	// Invoked once leadership is lost
+	OnStoppedLeading func()
+}
+
+// Elects a single leader amongst the replicas
+type Elector interface {
+	// Contends for leadership until the context is cancelled
+	Run(ctx context.Context, callbacks Callbacks)
+}
+
+// Elector for single replica deployments, always leads
+type Standalone struct{}
+
+func (Standalone) Run(ctx context.Context, callbacks Callbacks) {
+	callbacks.OnStartedLeading(ctx)
+	<-ctx.Done()
+	if callbacks.OnStoppedLeading != nil {
+		callbacks.OnStoppedLeading()
+	}
+}
+
+// Returns a unique identity for the replica, prefixed by the hostname
+func Identity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "cascade"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+}
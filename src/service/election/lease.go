@@ -0,0 +1,70 @@
+package election
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Elects the replica holding a Kubernetes Lease
+type Lease struct {
+	Lock *resourcelock.LeaseLock
+	// Duration non-leaders wait on before taking over the Lease
+	LeaseDuration time.Duration
+	// Interval between attempts at taking or renewing the Lease
+	RetryPeriod time.Duration
+	// Client side logger
+	Logger *zap.Logger
+}
+
+// Initializes a Lease elector, the Lease is created within the given namespace if missing
+func NewLease(client kubernetes.Interface, namespace string, name string, identity string, leaseDuration time.Duration, retryPeriod time.Duration, logger *zap.Logger) *Lease {
+	return &Lease{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Client: client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: identity,
+			},
+		},
+		LeaseDuration: leaseDuration,
+		RetryPeriod:   retryPeriod,
+		Logger:        logger,
+	}
+}
+
+func (elector *Lease) Run(ctx context.Context, callbacks Callbacks) {
+	// Keep contending for leadership after losing it
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            elector.Lock,
+			ReleaseOnCancel: true,
+			LeaseDuration:   elector.LeaseDuration,
+			RenewDeadline:   elector.LeaseDuration * 2 / 3,
+			RetryPeriod:     elector.RetryPeriod,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					elector.Logger.Info("Acquired leadership")
+					callbacks.OnStartedLeading(ctx)
+				},
+				OnStoppedLeading: func() {
+					elector.Logger.Info("Lost leadership")
+					if callbacks.OnStoppedLeading != nil {
+						callbacks.OnStoppedLeading()
+					}
+				},
+				OnNewLeader: func(identity string) {
+					elector.Logger.Info("Leader elected", zap.String("leader", identity))
+				},
+			},
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package election
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Key of the advisory lock guarding leadership
+const advisoryLockKey int64 = 0x63617363616465 // "cascade"
+
+// Takes and releases advisory locks, satisfied by the database client
+type AdvisoryLocker interface {
+	AcquireAdvisoryLock(ctx context.Context, key int64) (*sql.Conn, bool, error)
+	ReleaseAdvisoryLock(ctx context.Context, conn *sql.Conn, key int64) error
+}
+
+// Elects the replica holding a Postgres advisory lock
+type AdvisoryLock struct {
+	Locker AdvisoryLocker
+	// Interval between attempts at taking the lock, and checks on the held lock
+	RetryPeriod time.Duration
+	// Client side logger
+	Logger *zap.Logger
+}
+
+// Initializes an advisory lock elector
+func NewAdvisoryLock(locker AdvisoryLocker, retryPeriod time.Duration, logger *zap.Logger) *AdvisoryLock {
+	return &AdvisoryLock{
+		Locker:      locker,
+		RetryPeriod: retryPeriod,
+		Logger:      logger,
+	}
+}
+
+func (elector *AdvisoryLock) Run(ctx context.Context, callbacks Callbacks) {
+	ticker := time.NewTicker(elector.RetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		conn, acquired, err := elector.Locker.AcquireAdvisoryLock(ctx, advisoryLockKey)
+		if err != nil {
+			elector.Logger.Error("failed to acquire the advisory lock", zap.Error(err))
+		}
+		if acquired {
+			elector.lead(ctx, conn, callbacks)
+		}
+
+		select {
+		case <-ticker.C:
+			// contend again
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Leads until the context is cancelled or the connection holding the lock is lost
+func (elector *AdvisoryLock) lead(ctx context.Context, conn *sql.Conn, callbacks Callbacks) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	elector.Logger.Info("Acquired leadership")
+	go callbacks.OnStartedLeading(leaderCtx)
+
+	ticker := time.NewTicker(elector.RetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := conn.PingContext(ctx); err != nil {
+				// Postgres releases the lock along with the connection
+				elector.Logger.Error("lost the connection holding the advisory lock", zap.Error(err))
+				conn.Close()
+				elector.stop(cancel, callbacks)
+				return
+			}
+		case <-ctx.Done():
+			if err := elector.Locker.ReleaseAdvisoryLock(context.WithoutCancel(ctx), conn, advisoryLockKey); err != nil {
+				elector.Logger.Error("failed to release the advisory lock", zap.Error(err))
+			}
+			elector.stop(cancel, callbacks)
+			return
+		}
+	}
+}
+
+func (elector *AdvisoryLock) stop(cancel context.CancelFunc, callbacks Callbacks) {
+	cancel()
+	elector.Logger.Info("Lost leadership")
+	if callbacks.OnStoppedLeading != nil {
+		callbacks.OnStoppedLeading()
+	}
+}
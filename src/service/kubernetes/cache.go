@@ -0,0 +1,255 @@
+package k8x
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Indexers of the pod cache
+const (
+	NamespaceIndex = cache.NamespaceIndex // Pods by namespace
+	NodeIndex      = "node"               // Pods by the node they are scheduled onto
+	OwnerIndex     = "owner"              // Pods by their controller, keyed namespace/Kind/name
+)
+
+// Bounds the wait for the initial list, informers failing for reasons other than permissions retry until then
+const CacheSyncTimeout = time.Minute
+
+// Informer backed cache of the pods within a cluster, or a single namespace of it,
+// shared by every executor targeting the same cluster and namespace
+type PodCache struct {
+	key      string
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+	// Closed once the API Server forbids listing or watching pods, err holds the reason
+	forbidden chan struct{}
+	err       error
+	// Executors holding onto the cache, the informer stops once it drops to zero
+	refs int
+}
+
+var (
+	podCachesMu sync.Mutex
+	podCaches   = map[string]*PodCache{}
+)
+
+// Returns the pod cache of the cluster, starting its informer unless another executor already did.
+// Caches of a namespace only list and watch pods within it, metav1.NamespaceAll caches every pod.
+// Blocks until the cache is synced, every acquired cache must be released
+func AcquirePodCache(ctx context.Context, cluster string, namespace string, client kubernetes.Interface) (*PodCache, error) {
+	key := cluster + "/" + namespace
+
+	podCachesMu.Lock()
+	pc, ok := podCaches[key]
+	if !ok {
+		var err error
+		if pc, err = newPodCache(key, namespace, client); err != nil {
+			podCachesMu.Unlock()
+			return nil, err
+		}
+		podCaches[key] = pc
+	}
+	pc.refs++
+	podCachesMu.Unlock()
+
+	syncCtx, cancel := context.WithTimeout(ctx, CacheSyncTimeout)
+	defer cancel()
+	// Retrying a forbidden list never succeeds, stop waiting on it
+	go func() {
+		select {
+		case <-pc.forbidden:
+			cancel()
+		case <-syncCtx.Done():
+		}
+	}()
+
+	if !cache.WaitForCacheSync(syncCtx.Done(), pc.informer.HasSynced) {
+		pc.Release()
+		select {
+		case <-pc.forbidden:
+			return nil, fmt.Errorf("pod cache failed to sync: %w", pc.err)
+		default:
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("pod cache failed to sync within %s", CacheSyncTimeout)
+	}
+	return pc, nil
+}
+
+func newPodCache(key string, namespace string, client kubernetes.Interface) (*PodCache, error) {
+	// No resync, the watch keeps the cache up to date.
+	// Pod informers come indexed by namespace already
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace))
+	informer := factory.Core().V1().Pods().Informer()
+	err := informer.AddIndexers(cache.Indexers{
+		NodeIndex:  indexByNode,
+		OwnerIndex: indexByOwner,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &PodCache{
+		key:       key,
+		informer:  informer,
+		stop:      make(chan struct{}),
+		forbidden: make(chan struct{}),
+	}
+
+	var once sync.Once
+	err = informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		if apierrors.IsForbidden(err) {
+			once.Do(func() {
+				pc.err = err
+				close(pc.forbidden)
+			})
+		}
+		cache.DefaultWatchErrorHandler(r, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go informer.Run(pc.stop)
+	return pc, nil
+}
+
+// Releases the cache, stopping the informer once no executor holds onto it
+func (pc *PodCache) Release() {
+	podCachesMu.Lock()
+	defer podCachesMu.Unlock()
+
+	pc.refs--
+	if pc.refs > 0 {
+		return
+	}
+	close(pc.stop)
+	if podCaches[pc.key] == pc {
+		delete(podCaches, pc.key)
+	}
+}
+
+// Returns every cached pod
+func (pc *PodCache) List() []v1.Pod {
+	return toPods(pc.informer.GetIndexer().List())
+}
+
+// Returns the cached pods whose index value matches
+func (pc *PodCache) ByIndex(index string, value string) ([]v1.Pod, error) {
+	objs, err := pc.informer.GetIndexer().ByIndex(index, value)
+	if err != nil {
+		return nil, err
+	}
+	return toPods(objs), nil
+}
+
+// Returns the values the index currently holds, such as every namespace with pods
+func (pc *PodCache) IndexValues(index string) []string {
+	return pc.informer.GetIndexer().ListIndexFuncValues(index)
+}
+
+// Returns the cached pods controlled by the owner
+func (pc *PodCache) ByOwner(namespace string, kind string, name string) ([]v1.Pod, error) {
+	return pc.ByIndex(OwnerIndex, namespace+"/"+kind+"/"+name)
+}
+
+// Narrows the cached pods down through the namespace and node indexes,
+// the guards still run over the result so selection is the same as a list
+func (pc *PodCache) Select(target *TargetConfig) ([]v1.Pod, error) {
+	matches, err := namespaceMatcher(target.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.IncludedNodeNames != "" {
+		var pods []v1.Pod
+		for _, node := range pc.IndexValues(NodeIndex) {
+			if !containsAny(node, target.IncludedNodeNames) {
+				continue
+			}
+			scheduled, err := pc.ByIndex(NodeIndex, node)
+			if err != nil {
+				return nil, err
+			}
+			for _, pod := range scheduled {
+				if matches(pod.Namespace) {
+					pods = append(pods, pod)
+				}
+			}
+		}
+		return pods, nil
+	}
+
+	if target.Namespaces.Empty() {
+		return pc.List(), nil
+	}
+
+	var pods []v1.Pod
+	for _, namespace := range pc.IndexValues(NamespaceIndex) {
+		if !matches(namespace) {
+			continue
+		}
+		scheduled, err := pc.ByIndex(NamespaceIndex, namespace)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, scheduled...)
+	}
+	return pods, nil
+}
+
+func indexByNode(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+func indexByOwner(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil, nil
+	}
+	return []string{pod.Namespace + "/" + owner.Kind + "/" + owner.Name}, nil
+}
+
+// Copies the cached pods, so callers never mutate the cache
+func toPods(objs []interface{}) []v1.Pod {
+	pods := make([]v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pods = append(pods, *pod.DeepCopy())
+		}
+	}
+	return pods
+}
+
+// Identifies the cluster a config reaches, executors sharing the key share the pod cache
+func clusterKey(cc *ClusterConfig) string {
+	data, _ := json.Marshal(struct {
+		*ClusterConfig
+		Credentials *ClusterCredentials `json:"credentials,omitempty"`
+	}{cc, cc.Credentials})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package k8x
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAcquirePodCacheWithinNamespace(t *testing.T) {
+	pods := testPods(4)
+	client := fake.NewSimpleClientset(&pods[0], &pods[1], &pods[2], &pods[3])
+
+	pc, err := AcquirePodCache(context.Background(), t.Name(), "ns-1", client)
+	if err != nil {
+		t.Fatalf("AcquirePodCache() = %v", err)
+	}
+	defer pc.Release()
+
+	for _, pod := range pc.List() {
+		if pod.Namespace != "ns-1" {
+			t.Fatalf("cache holds pod %s/%s, want pods within ns-1 only", pod.Namespace, pod.Name)
+		}
+	}
+	if got := len(pc.List()); got != 2 {
+		t.Fatalf("cache holds %d pods, want 2", got)
+	}
+}
+
+func TestAcquirePodCacheForbidden(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", nil)
+	})
+
+	start := time.Now()
+	_, err := AcquirePodCache(context.Background(), t.Name(), metav1.NamespaceAll, client)
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("AcquirePodCache() = %v, want a forbidden error", err)
+	}
+	if elapsed := time.Since(start); elapsed >= CacheSyncTimeout {
+		t.Fatalf("AcquirePodCache() gave up after %s, want it to stop before %s", elapsed, CacheSyncTimeout)
+	}
+}
@@ -0,0 +1,49 @@
+package k8x
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Validate checks that the credentials are self-contained, since they're used by the API Server on behalf of its users
+// Kubeconfigs may only carry inline certificates and tokens, exec plugins, auth providers and file references are rejected
+func (creds *ClusterCredentials) Validate() error {
+	if len(creds.Kubeconfig) == 0 {
+		return nil
+	}
+
+	raw, err := clientcmd.Load(creds.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	return validateKubeconfig(raw)
+}
+
+func validateKubeconfig(raw *clientcmdapi.Config) error {
+	for name, cluster := range raw.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("cluster %q references certificate-authority, use certificate-authority-data instead", name)
+		}
+	}
+
+	for name, user := range raw.AuthInfos {
+		switch {
+		case user.Exec != nil:
+			return fmt.Errorf("user %q uses an exec plugin, only inline credentials are accepted", name)
+		case user.AuthProvider != nil:
+			return fmt.Errorf("user %q uses an auth provider, only inline credentials are accepted", name)
+		case user.TokenFile != "":
+			return fmt.Errorf("user %q references tokenFile, use token instead", name)
+		case user.ClientCertificate != "":
+			return fmt.Errorf("user %q references client-certificate, use client-certificate-data instead", name)
+		case user.ClientKey != "":
+			return fmt.Errorf("user %q references client-key, use client-key-data instead", name)
+		case user.Username != "" || user.Password != "":
+			return fmt.Errorf("user %q uses basic auth, only client certificates and tokens are accepted", name)
+		}
+	}
+
+	return nil
+}
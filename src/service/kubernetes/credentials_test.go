@@ -0,0 +1,106 @@
+package k8x
+
+import (
+	"strings"
+	"testing"
+)
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+%s
+users:
+- name: staging
+  user:
+%s
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: staging
+`
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster string
+		user    string
+		wantErr string
+	}{
+		{
+			name:    "inline certificates",
+			cluster: "    certificate-authority-data: Y2E=",
+			user:    "    client-certificate-data: Y2VydA==\n    client-key-data: a2V5",
+		},
+		{
+			name: "inline token",
+			user: "    token: secret",
+		},
+		{
+			name:    "certificate authority file",
+			cluster: "    certificate-authority: /etc/kubernetes/ca.crt",
+			user:    "    token: secret",
+			wantErr: "certificate-authority",
+		},
+		{
+			name:    "exec plugin",
+			user:    "    exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: /bin/sh",
+			wantErr: "exec plugin",
+		},
+		{
+			name:    "auth provider",
+			user:    "    auth-provider:\n      name: gcp",
+			wantErr: "auth provider",
+		},
+		{
+			name:    "token file",
+			user:    "    tokenFile: /var/run/secrets/token",
+			wantErr: "tokenFile",
+		},
+		{
+			name:    "client certificate file",
+			user:    "    client-certificate: /root/client.crt\n    client-key-data: a2V5",
+			wantErr: "client-certificate",
+		},
+		{
+			name:    "client key file",
+			user:    "    client-certificate-data: Y2VydA==\n    client-key: /root/client.key",
+			wantErr: "client-key",
+		},
+		{
+			name:    "basic auth",
+			user:    "    username: admin\n    password: admin",
+			wantErr: "basic auth",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := &ClusterCredentials{
+				Kubeconfig: []byte(strings.Replace(strings.Replace(kubeconfigTemplate, "%s", tt.cluster, 1), "%s", tt.user, 1)),
+			}
+
+			err := creds.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCredentialsWithoutKubeconfig(t *testing.T) {
+	creds := &ClusterCredentials{Token: "secret", CAData: []byte("ca")}
+	if err := creds.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+}
@@ -3,9 +3,15 @@ package k8x
 import (
 	"context"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -27,6 +33,15 @@ type Executor struct {
 	Runtime *RuntimeConfig
 	// Client side logger
 	Logger *zap.Logger
+	// Informer cache candidates are read from, pods are listed off the API Server if nil
+	Cache *PodCache
+
+	// Identifies the cluster, so executors targeting it share a pod cache
+	cluster string
+	// Delivers the recorded events, until the executor is closed
+	broadcaster record.EventBroadcaster
+	// Ticks which selected victims so far
+	ticks uint64
 }
 
 // Initializes an executor instance
@@ -36,7 +51,7 @@ func CreateExecutor(cc *ClusterConfig, tc *TargetConfig, rc *RuntimeConfig, logg
 		return nil, err
 	}
 
-	recorder := getEventRecorder(client)
+	broadcaster, recorder := getEventRecorder(client)
 
 	return &Executor{
 		Client:        client,   // Kubernetes Client Instance
@@ -44,15 +59,70 @@ func CreateExecutor(cc *ClusterConfig, tc *TargetConfig, rc *RuntimeConfig, logg
 		Target:        tc,
 		Runtime:       rc,
 		Logger:        logger,
+		cluster:       clusterKey(cc),
+		broadcaster:   broadcaster,
 	}, nil
 }
 
+// Reads candidates out of the pod cache shared by every executor targeting the same cluster,
+// targets naming a single namespace only cache the pods within it
+func (executor *Executor) ShareCache(ctx context.Context) error {
+	if executor.Cache != nil {
+		return nil
+	}
+	namespace := listNamespace(executor.Target.Namespaces)
+	cache, err := AcquirePodCache(ctx, executor.cluster, namespace, executor.Client)
+	if err != nil {
+		return err
+	}
+	executor.Cache = cache
+	return nil
+}
+
+// Releases the pod cache held by the executor, and stops delivering its events
+func (executor *Executor) Close() {
+	if executor.Cache != nil {
+		executor.Cache.Release()
+		executor.Cache = nil
+	}
+	if executor.broadcaster != nil {
+		executor.broadcaster.Shutdown()
+		executor.broadcaster = nil
+	}
+}
+
+// Returns Kubernetes Client for reaching the cluster
+func NewClient(cc *ClusterConfig) (kubernetes.Interface, error) {
+	return getK8Client(cc)
+}
+
 // Returns Kubernetes Client
 func getK8Client(cc *ClusterConfig) (*kubernetes.Clientset, error) {
+	config, err := RestConfig(cc)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Returns the client config for reaching the cluster
+func RestConfig(cc *ClusterConfig) (*rest.Config, error) {
 	var config *rest.Config
 	var err error
 
-	if cc.Origin == "cluster" {
+	if cc.Credentials != nil {
+		// Use the credentials of a registered cluster
+		config, err = getRegisteredClusterConfig(cc)
+		if err != nil {
+			return nil, err
+		}
+	} else if cc.Origin == "cluster" {
 		// Get the in-cluster config
 		config, err = rest.InClusterConfig()
 		if err != nil {
@@ -66,50 +136,185 @@ func getK8Client(cc *ClusterConfig) (*kubernetes.Clientset, error) {
 			}
 		}
 
-		config, err = clientcmd.BuildConfigFromFlags(cc.Master, cc.Kubeconfig)
+		if cc.Context != "" {
+			// Pick the context out of the kubeconfig
+			rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: cc.Kubeconfig}
+			overrides := &clientcmd.ConfigOverrides{CurrentContext: cc.Context}
+			overrides.ClusterInfo.Server = cc.Master
+			config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+		} else {
+			config, err = clientcmd.BuildConfigFromFlags(cc.Master, cc.Kubeconfig)
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	client, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
+	// Trace every call made against the API Server
+	config.Wrap(tracing.Transport)
+
+	return config, nil
+}
+
+// Returns the client config for a registered cluster
+func getRegisteredClusterConfig(cc *ClusterConfig) (*rest.Config, error) {
+	creds := cc.Credentials
+
+	var config *rest.Config
+	if len(creds.Kubeconfig) > 0 {
+		raw, err := clientcmd.Load(creds.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		// Credentials registered before they were validated are checked once more
+		if err := validateKubeconfig(raw); err != nil {
+			return nil, err
+		}
+
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: creds.Context}
+		config, err = clientcmd.NewDefaultClientConfig(*raw, overrides).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		config = &rest.Config{
+			BearerToken: creds.Token,
+			TLSClientConfig: rest.TLSClientConfig{
+				CAData:   creds.CAData,
+				Insecure: creds.Insecure,
+			},
+		}
 	}
 
-	return client, nil
+	if cc.Server != "" {
+		config.Host = cc.Server
+	}
+	if config.Host == "" {
+		return nil, errMissingServer
+	}
+
+	return config, nil
 }
 
-// Returns an EventRecorder that can be used to log events via EventRecorder Controller
-func getEventRecorder(client *kubernetes.Clientset) record.EventRecorderLogger {
+// Returns an EventRecorder that can be used to log events via EventRecorder Controller,
+// along with the broadcaster delivering them which must be shut down
+func getEventRecorder(client *kubernetes.Clientset) (record.EventBroadcaster, record.EventRecorderLogger) {
 	broadcaster := record.NewBroadcaster()
 	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(v1.NamespaceAll)})
 	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "cascade"})
-	return recorder
+	return broadcaster, recorder
 }
 
 // Execute the chaos engineering scenario
 // Return an error incase, pods deletion got interupped
 func (executor *Executor) Execute(ctx context.Context) error {
+	_, err := executor.execute(ctx)
+	return err
+}
+
+// Execute the chaos engineering scenario, returns the number of victims acted upon
+func (executor *Executor) execute(ctx context.Context) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade.execute")
+	defer span.End()
+
 	// Identify the pods to kill
 	podsToKill, err := executor.SelectPodsToKill(ctx)
-	if err != nil {
-		return err
-	}
 	if err == errPodNotFound {
 		executor.Logger.Debug(podNotFound)
-		return nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
 	}
+	span.SetAttributes(tracing.VictimsKey.Int(len(podsToKill)))
 
 	// Trigger deletion
-	var result *multierror.Error
-	for _, victim := range podsToKill {
-		err = executor.DeletePod(victim, ctx)
-		if err != nil {
-			executor.Logger.Error("failed to delete pod", zap.Any("pod", victim.Name))
-			result = multierror.Append(result, err)
+	acted, result := executor.actOnVictims(ctx, podsToKill)
+
+	if err := result.ErrorOrNil(); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return acted, err
+	}
+
+	return acted, nil
+}
+
+// Acts upon the victims through a pool of workers, bounded by the concurrency and rate limit of the runtime.
+// Actions start in kill order, victims left once the context is cancelled are left alone
+func (executor *Executor) actOnVictims(ctx context.Context, victims []v1.Pod) (int, *multierror.Error) {
+	rc := executor.Runtime
+
+	var limiter *rate.Limiter
+	if rc.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(rc.RateLimit), 1)
+	}
+	slots := make(chan struct{}, max(rc.Concurrency, 1))
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result *multierror.Error
+		acted  int
+	)
+
+	started := 0
+	for i, victim := range victims {
+		if i > 0 && !wait(ctx, rc.delay()) {
+			break
+		}
+		if limiter != nil && limiter.Wait(ctx) != nil {
+			break
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
 		}
+		started++
+
+		wg.Add(1)
+		go func(victim v1.Pod) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := executor.DeletePod(victim, ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				executor.Logger.Error("failed to delete pod", log.Event(log.EventActionFailed),
+					zap.String("pod", victim.Name),
+					zap.String("namespace", victim.Namespace),
+					zap.Error(err),
+				)
+				result = multierror.Append(result, err)
+				return
+			}
+			acted++
+		}(victim)
+	}
+	wg.Wait()
+
+	if skipped := len(victims) - started; skipped > 0 {
+		executor.Logger.Warn("Run cancelled, victims left alone", zap.Int("skipped", skipped))
 	}
 
-	return result.ErrorOrNil()
+	return acted, result
+}
+
+// Waits for the delay, returns false if the context is cancelled first
+func wait(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
@@ -0,0 +1,281 @@
+package k8x
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+var errRoundsRequired = errors.New("sequential fan-out requires a bounded number of rounds")
+
+// Determines how a scenario is spread across clusters
+type FanOutStrategy int
+
+const (
+	Parallel   FanOutStrategy = iota // Run on every cluster at once
+	Sequential                       // Run on one cluster after another
+)
+
+// ParseFanOutStrategy converts a string representation of FanOutStrategy to its enum value.
+func ParseFanOutStrategy(strategyStr string) (FanOutStrategy, error) {
+	switch strategyStr {
+	case "parallel":
+		return Parallel, nil
+	case "sequential":
+		return Sequential, nil
+	default:
+		return Parallel, fmt.Errorf("unknown fan-out strategy %q, expected parallel or sequential", strategyStr)
+	}
+}
+
+// Returns the string representation of the FanOutStrategy
+func (strategy FanOutStrategy) String() string {
+	switch strategy {
+	case Sequential:
+		return "sequential"
+	default:
+		return "parallel"
+	}
+}
+
+// Determines how the scenario is spread across clusters
+type FanOutConfig struct {
+	// Run on every cluster at once, or one after another
+	Strategy FanOutStrategy `json:"strategy" yaml:"strategy"`
+	// Pause between clusters, only applies to sequential runs
+	Pause time.Duration `json:"pause" yaml:"pause"`
+	// Rounds to run on each cluster, zero runs until cancelled
+	Rounds int `json:"rounds" yaml:"rounds"`
+}
+
+// Status of a cluster within a fan-out
+const (
+	ClusterPending   = "pending"
+	ClusterRunning   = "running"
+	ClusterCompleted = "completed"
+	ClusterFailed    = "failed"
+	ClusterCancelled = "cancelled"
+)
+
+// Executor bound to a single cluster of the fan-out
+type FanOutTarget struct {
+	// Registered cluster ID or kube context, identifies the cluster in reports
+	Name     string
+	Executor *Executor
+}
+
+// Outcome of the scenario on a single cluster
+type ClusterReport struct {
+	Cluster   string    `json:"cluster"`
+	Status    string    `json:"status"`
+	Rounds    int       `json:"rounds"`
+	Victims   int       `json:"victims"`
+	Failures  int       `json:"failures"`
+	Error     string    `json:"error,omitempty"`
+	StartTime time.Time `json:"start_time,omitempty"`
+	EndTime   time.Time `json:"end_time,omitempty"`
+	// When the next round is due, zero once no round is left
+	NextRound time.Time `json:"next_round,omitempty"`
+}
+
+// Combined outcome of the scenario across clusters
+type FanOutReport struct {
+	Strategy string          `json:"strategy"`
+	Status   string          `json:"status"`
+	Victims  int             `json:"victims"`
+	Failures int             `json:"failures"`
+	Clusters []ClusterReport `json:"clusters"`
+}
+
+// Runs the same scenario across several clusters
+type FanOut struct {
+	Targets []FanOutTarget
+	Config  *FanOutConfig
+	// Client side logger
+	Logger *zap.Logger
+	// Invoked whenever the status of a cluster changes, must be safe for concurrent use
+	OnUpdate func(ClusterReport)
+}
+
+// Initializes a fan-out instance
+func CreateFanOut(targets []FanOutTarget, fc *FanOutConfig, logger *zap.Logger) (*FanOut, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("fan-out requires at least one cluster")
+	}
+	if fc.Strategy == Sequential && fc.Rounds <= 0 {
+		return nil, errRoundsRequired
+	}
+
+	return &FanOut{
+		Targets: targets,
+		Config:  fc,
+		Logger:  logger,
+	}, nil
+}
+
+// Run the scenario across the clusters, returns once every cluster is done or the context is cancelled
+func (fanout *FanOut) Run(ctx context.Context) *FanOutReport {
+	reports := make([]ClusterReport, len(fanout.Targets))
+	for i, target := range fanout.Targets {
+		reports[i] = ClusterReport{Cluster: target.Name, Status: ClusterPending}
+	}
+
+	switch fanout.Config.Strategy {
+	case Sequential:
+		for i, target := range fanout.Targets {
+			if ctx.Err() != nil {
+				fanout.cancel(&reports[i])
+				continue
+			}
+
+			fanout.runTarget(ctx, target, &reports[i])
+
+			// Pause before moving onto the next cluster
+			if i < len(fanout.Targets)-1 && fanout.Config.Pause > 0 {
+				fanout.Logger.Info("Pausing before the next cluster", zap.Duration("pause", fanout.Config.Pause))
+				select {
+				case <-time.After(fanout.Config.Pause):
+				case <-ctx.Done():
+				}
+			}
+		}
+	default:
+		var wg sync.WaitGroup
+		for i, target := range fanout.Targets {
+			wg.Add(1)
+			go func(target FanOutTarget, report *ClusterReport) {
+				defer wg.Done()
+				fanout.runTarget(ctx, target, report)
+			}(target, &reports[i])
+		}
+		wg.Wait()
+	}
+
+	return Summarize(fanout.Config.Strategy.String(), reports)
+}
+
+// Releases the executor of every cluster
+func (fanout *FanOut) Close() {
+	for _, target := range fanout.Targets {
+		target.Executor.Close()
+	}
+}
+
+// Run the scenario on a single cluster, round after round
+func (fanout *FanOut) runTarget(ctx context.Context, target FanOutTarget, report *ClusterReport) {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade.cluster")
+	span.SetAttributes(tracing.ClusterKey.String(target.Name))
+	defer span.End()
+
+	executor := target.Executor
+	report.Status = ClusterRunning
+	report.StartTime = time.Now()
+	report.NextRound = report.StartTime.Add(executor.Runtime.InitialDelay)
+	fanout.update(*report)
+	executor.Logger.Info("Cluster run started", log.Event(log.EventClusterStarted))
+
+	// Read candidates out of the pod cache shared across sessions, rather than listing pods every tick
+	if err := executor.ShareCache(ctx); err != nil {
+		executor.Logger.Warn("Pod cache unavailable, listing pods every tick", zap.Error(err))
+	}
+	defer executor.Close()
+
+	schedule := NewSchedule(executor.Runtime)
+	running := schedule.WaitInitial(ctx)
+	for running {
+		start := time.Now()
+		executor.Logger.Info("Chaos Scenario", log.Event(log.EventTickStarted), zap.Int("round", report.Rounds+1))
+
+		// Trigger Execution
+		victims, err := executor.execute(ctx)
+		report.Rounds++
+		report.Victims += victims
+		if err != nil {
+			report.Failures++
+			report.Error = err.Error()
+			executor.Logger.Error(err.Error())
+		}
+
+		// Schedule the next round ahead of reporting, so reports count down to it
+		last := fanout.Config.Rounds > 0 && report.Rounds >= fanout.Config.Rounds
+		report.NextRound = time.Time{}
+		if !last {
+			report.NextRound = schedule.NextAt(start)
+		}
+		fanout.update(*report)
+		if last {
+			break
+		}
+
+		// Skip subsequent rounds once cancelled
+		running = schedule.WaitUntil(ctx, report.NextRound)
+	}
+
+	report.EndTime = time.Now()
+	report.NextRound = time.Time{}
+	switch {
+	case report.Failures > 0:
+		report.Status = ClusterFailed
+		span.SetStatus(codes.Error, report.Error)
+	case fanout.Config.Rounds > 0 && report.Rounds < fanout.Config.Rounds:
+		report.Status = ClusterCancelled
+	default:
+		report.Status = ClusterCompleted
+	}
+	fanout.update(*report)
+
+	executor.Logger.Info("Cluster run ended", log.Event(log.EventClusterEnded),
+		zap.String("status", report.Status),
+		zap.Int("rounds", report.Rounds),
+		zap.Int("victims", report.Victims),
+		zap.Int("failures", report.Failures),
+	)
+}
+
+// Mark a cluster which never got to run as cancelled
+func (fanout *FanOut) cancel(report *ClusterReport) {
+	report.Status = ClusterCancelled
+	fanout.update(*report)
+}
+
+func (fanout *FanOut) update(report ClusterReport) {
+	if fanout.OnUpdate != nil {
+		fanout.OnUpdate(report)
+	}
+}
+
+// Combines the outcome of every cluster onto a single report
+func Summarize(strategy string, reports []ClusterReport) *FanOutReport {
+	summary := &FanOutReport{
+		Strategy: strategy,
+		Status:   ClusterCompleted,
+		Clusters: reports,
+	}
+
+	for _, report := range reports {
+		summary.Victims += report.Victims
+		summary.Failures += report.Failures
+
+		switch report.Status {
+		case ClusterFailed:
+			summary.Status = ClusterFailed
+		case ClusterPending, ClusterRunning:
+			if summary.Status != ClusterFailed {
+				summary.Status = ClusterRunning
+			}
+		case ClusterCancelled:
+			if summary.Status == ClusterCompleted {
+				summary.Status = ClusterCancelled
+			}
+		}
+	}
+
+	return summary
+}
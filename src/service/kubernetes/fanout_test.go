@@ -0,0 +1,29 @@
+package k8x
+
+import "testing"
+
+func TestParseFanOutStrategy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    FanOutStrategy
+		wantErr bool
+	}{
+		{in: "parallel", want: Parallel},
+		{in: "sequential", want: Sequential},
+		{in: "", wantErr: true},
+		{in: "serial", wantErr: true},
+		{in: "Parallel", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseFanOutStrategy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFanOutStrategy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != tt.in) {
+				t.Fatalf("ParseFanOutStrategy(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
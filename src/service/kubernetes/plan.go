@@ -0,0 +1,146 @@
+package k8x
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/wizenheimer/cascade/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Preview of the pods a tick would act upon, computed without acting
+type Plan struct {
+	Mode     string  `json:"mode"`
+	Ordering string  `json:"ordering"`
+	Ratio    float64 `json:"ratio"`
+	Seed     int64   `json:"seed"`
+	// Pods which went through every guard
+	Candidates int `json:"candidates"`
+	// Pods sampled out of the candidates
+	Victims int          `json:"victims"`
+	Pods    []PlannedPod `json:"pods"`
+}
+
+// Pod considered by a plan
+type PlannedPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Node      string `json:"node,omitempty"`
+	// Workload owning the pod, such as Deployment/api
+	Workload  string     `json:"workload,omitempty"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	Candidate bool       `json:"candidate"`
+	Sampled   bool       `json:"sampled"`
+	// Position within the kill order starting at 1, zero for pods left alone
+	Order int `json:"order,omitempty"`
+	// Guards which would skip the pod
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Runs candidate selection and sampling exactly as the first tick would, without acting upon any pod.
+// Sessions replaying the seed of the plan select the same victims, as long as the candidates remain the same
+func (executor *Executor) Plan(ctx context.Context) (*Plan, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade.plan")
+	defer span.End()
+
+	pods, err := executor.listPods(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	plan := &Plan{
+		Mode:     executor.Runtime.Mode.String(),
+		Ordering: executor.Runtime.Order.String(),
+		Ratio:    executor.Runtime.Ratio,
+		Seed:     executor.Runtime.Seed,
+		Pods:     make([]PlannedPod, len(pods)),
+	}
+
+	// Evaluate every guard against every pod, so each pod lists all the guards skipping it
+	guards := executor.podGuards()
+	var candidates []v1.Pod
+	index := make(map[string]int, len(pods))
+	workloads := make(map[string]string)
+	for i, pod := range pods {
+		planned := PlannedPod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Node:      pod.Spec.NodeName,
+			Workload:  executor.workload(ctx, pod, workloads),
+		}
+		if pod.Status.StartTime != nil {
+			planned.StartTime = &pod.Status.StartTime.Time
+		}
+
+		for _, guard := range guards {
+			kept, err := guard.Filter([]v1.Pod{pod})
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return nil, err
+			}
+			if len(kept) == 0 {
+				planned.Skipped = append(planned.Skipped, guard.Name)
+			}
+		}
+
+		if len(planned.Skipped) == 0 {
+			planned.Candidate = true
+			candidates = append(candidates, pod)
+		}
+		plan.Pods[i] = planned
+		index[pod.Namespace+"/"+pod.Name] = i
+	}
+	plan.Candidates = len(candidates)
+
+	// Sample and order the candidates the same way a tick would
+	victims := executor.sample(ctx, candidates, 0)
+	plan.Victims = len(victims)
+	for order, victim := range victims {
+		planned := &plan.Pods[index[victim.Namespace+"/"+victim.Name]]
+		planned.Sampled = true
+		planned.Order = order + 1
+	}
+
+	// Victims come first in kill order, followed by the pods left alone
+	sort.SliceStable(plan.Pods, func(i, j int) bool {
+		a, b := plan.Pods[i], plan.Pods[j]
+		if a.Sampled != b.Sampled {
+			return a.Sampled
+		}
+		return a.Order < b.Order
+	})
+
+	return plan, nil
+}
+
+// Resolves the workload owning the pod, following ReplicaSets up to their Deployment
+func (executor *Executor) workload(ctx context.Context, pod v1.Pod, cache map[string]string) string {
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil {
+		return ""
+	}
+	if owner.Kind != "ReplicaSet" {
+		return owner.Kind + "/" + owner.Name
+	}
+
+	key := pod.Namespace + "/" + owner.Name
+	if workload, ok := cache[key]; ok {
+		return workload
+	}
+
+	workload := owner.Kind + "/" + owner.Name
+	rs, err := executor.Client.AppsV1().ReplicaSets(pod.Namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+	if err == nil {
+		if deployment := metav1.GetControllerOf(rs); deployment != nil {
+			workload = deployment.Kind + "/" + deployment.Name
+		}
+	}
+	cache[key] = workload
+	return workload
+}
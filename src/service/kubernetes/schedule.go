@@ -0,0 +1,102 @@
+package k8x
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Determines how intervals between ticks are drawn
+type IntervalDistribution int
+
+const (
+	Fixed       IntervalDistribution = iota // Every tick is an interval apart
+	Uniform                                 // Intervals drawn uniformly between the bounds
+	Exponential                             // Intervals drawn exponentially around the interval, clamped to the bounds
+)
+
+// ParseIntervalDistribution converts a string representation of IntervalDistribution to its enum value.
+func ParseIntervalDistribution(distributionStr string) (IntervalDistribution, error) {
+	switch distributionStr {
+	case "fixed":
+		return Fixed, nil
+	case "uniform":
+		return Uniform, nil
+	case "exponential":
+		return Exponential, nil
+	default:
+		return Fixed, fmt.Errorf("unknown distribution %q, expected fixed, uniform or exponential", distributionStr)
+	}
+}
+
+// Returns the string representation of the IntervalDistribution
+func (distribution IntervalDistribution) String() string {
+	switch distribution {
+	case Uniform:
+		return "uniform"
+	case Exponential:
+		return "exponential"
+	default:
+		return "fixed"
+	}
+}
+
+// Stream of the schedule's draws, kept apart from the streams of victim selection
+const scheduleStream = 1<<64 - 1
+
+// Draws the delays between the ticks of a session
+type Schedule struct {
+	runtime *RuntimeConfig
+	rng     *rand.Rand
+}
+
+// Returns the schedule of the runtime, draws are derived from the seed so replaying a seed replays the schedule
+func NewSchedule(rc *RuntimeConfig) *Schedule {
+	return &Schedule{
+		runtime: rc,
+		rng:     rand.New(rand.NewPCG(uint64(rc.Seed), scheduleStream)),
+	}
+}
+
+// Returns the delay before the next tick, counted from the start of the previous one
+func (schedule *Schedule) Next() time.Duration {
+	rc := schedule.runtime
+
+	interval := rc.Interval
+	switch rc.Distribution {
+	case Uniform:
+		interval = rc.MinInterval
+		if spread := rc.MaxInterval - rc.MinInterval; spread > 0 {
+			interval += time.Duration(schedule.rng.Int64N(int64(spread)))
+		}
+	case Exponential:
+		interval = time.Duration(schedule.rng.ExpFloat64() * float64(rc.Interval))
+		interval = max(interval, rc.MinInterval)
+		if rc.MaxInterval > 0 {
+			interval = min(interval, rc.MaxInterval)
+		}
+	}
+
+	// Jitter spreads the interval by up to ±IntervalJitter of itself
+	if rc.IntervalJitter > 0 {
+		spread := (schedule.rng.Float64()*2 - 1) * rc.IntervalJitter
+		interval += time.Duration(spread * float64(interval))
+	}
+	return max(interval, 0)
+}
+
+// Waits out the initial delay, returns false if the context is cancelled first
+func (schedule *Schedule) WaitInitial(ctx context.Context) bool {
+	return wait(ctx, schedule.runtime.InitialDelay)
+}
+
+// Returns when the next tick is due, counted from the start of the previous one
+func (schedule *Schedule) NextAt(last time.Time) time.Time {
+	return last.Add(schedule.Next())
+}
+
+// Waits until the tick is due, returns false if the context is cancelled first
+func (schedule *Schedule) WaitUntil(ctx context.Context, due time.Time) bool {
+	return wait(ctx, time.Until(due))
+}
@@ -0,0 +1,116 @@
+package k8x
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseIntervalDistribution(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    IntervalDistribution
+		wantErr bool
+	}{
+		{in: "fixed", want: Fixed},
+		{in: "uniform", want: Uniform},
+		{in: "exponential", want: Exponential},
+		{in: "", wantErr: true},
+		{in: "poisson", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseIntervalDistribution(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIntervalDistribution(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != tt.in) {
+				t.Fatalf("ParseIntervalDistribution(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		rc       RuntimeConfig
+		min, max time.Duration
+	}{
+		{
+			name: "fixed",
+			rc:   RuntimeConfig{Distribution: Fixed, Interval: 10 * time.Second},
+			min:  10 * time.Second, max: 10 * time.Second,
+		},
+		{
+			name: "uniform",
+			rc:   RuntimeConfig{Distribution: Uniform, MinInterval: 5 * time.Second, MaxInterval: 15 * time.Second},
+			min:  5 * time.Second, max: 15 * time.Second,
+		},
+		{
+			name: "uniform without spread",
+			rc:   RuntimeConfig{Distribution: Uniform, MinInterval: 5 * time.Second, MaxInterval: 5 * time.Second},
+			min:  5 * time.Second, max: 5 * time.Second,
+		},
+		{
+			name: "exponential clamped",
+			rc:   RuntimeConfig{Distribution: Exponential, Interval: 10 * time.Second, MinInterval: 8 * time.Second, MaxInterval: 12 * time.Second},
+			min:  8 * time.Second, max: 12 * time.Second,
+		},
+		{
+			name: "exponential floor only",
+			rc:   RuntimeConfig{Distribution: Exponential, Interval: 10 * time.Second, MinInterval: 2 * time.Second},
+			min:  2 * time.Second, max: time.Duration(math.MaxInt64),
+		},
+		{
+			name: "fixed with jitter",
+			rc:   RuntimeConfig{Distribution: Fixed, Interval: 10 * time.Second, IntervalJitter: 0.2},
+			min:  8 * time.Second, max: 12 * time.Second,
+		},
+		{
+			name: "uniform with jitter",
+			rc:   RuntimeConfig{Distribution: Uniform, MinInterval: 10 * time.Second, MaxInterval: 20 * time.Second, IntervalJitter: 0.5},
+			min:  5 * time.Second, max: 30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := NewSchedule(&tt.rc)
+			for i := 0; i < 1000; i++ {
+				if got := schedule.Next(); got < tt.min || got > tt.max {
+					t.Fatalf("draw %d: Next() = %s, want within [%s, %s]", i, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleReplay(t *testing.T) {
+	rc := RuntimeConfig{
+		Seed:           42,
+		Distribution:   Exponential,
+		Interval:       10 * time.Second,
+		MinInterval:    time.Second,
+		MaxInterval:    time.Minute,
+		IntervalJitter: 0.1,
+	}
+
+	first, second := NewSchedule(&rc), NewSchedule(&rc)
+	for i := 0; i < 100; i++ {
+		if a, b := first.Next(), second.Next(); a != b {
+			t.Fatalf("draw %d = %s and %s, want equal draws for the same seed", i, a, b)
+		}
+	}
+
+	other := rc
+	other.Seed = 43
+	a, b := NewSchedule(&rc), NewSchedule(&other)
+	for i := 0; i < 100; i++ {
+		if a.Next() != b.Next() {
+			return
+		}
+	}
+	t.Fatalf("seeds 42 and 43 drew the same schedule")
+}
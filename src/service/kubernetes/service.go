@@ -3,8 +3,11 @@ package k8x
 import (
 	"context"
 	"fmt"
-	"time"
 
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,11 +17,16 @@ import (
 
 // Selects the Pods to Kill
 func (executor *Executor) SelectPodsToKill(ctx context.Context) ([]v1.Pod, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade.select")
+	defer span.End()
+
 	// Figure out the Candidate Pods
 	executor.Logger.Info("Preparing Candidate Pods")
 	pods, err := executor.SelectCandidatePods(ctx)
 	if err != nil {
 		executor.Logger.Error("Error occured while preparing Candidate Pods")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return []v1.Pod{}, err
 	}
 	if len(pods) == 0 {
@@ -26,69 +34,176 @@ func (executor *Executor) SelectPodsToKill(ctx context.Context) ([]v1.Pod, error
 		return []v1.Pod{}, errPodNotFound
 	}
 
+	pods = executor.sample(ctx, pods, executor.nextTick())
+	for _, pod := range pods {
+		executor.Logger.Info("Victim selected", log.Event(log.EventVictimSelected),
+			zap.String("pod", pod.Name),
+			zap.String("namespace", pod.Namespace),
+			zap.String("node", pod.Spec.NodeName),
+		)
+	}
+
+	return pods, nil
+}
+
+// Returns the index of the tick about to select victims
+func (executor *Executor) nextTick() uint64 {
+	tick := executor.ticks
+	executor.ticks++
+	return tick
+}
+
+// Samples the victims out of the candidate pods, and reorders them.
+// Draws are derived from the seed and the tick, so replaying a seed selects the same victims out of the same candidates
+func (executor *Executor) sample(ctx context.Context, pods []v1.Pod, tick uint64) []v1.Pod {
+	rng := selectionRand(executor.Runtime.Seed, tick)
+	canonicalOrdering(pods)
+
 	// Prepare a Random Pod Slice
-	executor.Logger.Info("Sampling from a list of candidate pods")
-	pods = RandomPodSlice(pods, executor.Runtime.Ratio)
+	executor.Logger.Info("Sampling from a list of candidate pods", zap.Int64("seed", executor.Runtime.Seed), zap.Uint64("tick", tick))
+	_, sampleSpan := tracing.Tracer().Start(ctx, "cascade.sample")
+	sampleSpan.SetAttributes(tracing.SeedKey.Int64(executor.Runtime.Seed), tracing.TickKey.Int64(int64(tick)))
+	pods = RandomPodSlice(pods, executor.Runtime.Victims(len(pods)), rng)
+	sampleSpan.SetAttributes(tracing.VictimsKey.Int(len(pods)))
+	sampleSpan.End()
 
 	// Reorder the Pods
 	executor.Logger.Info("Reordering the Pods")
-	reorderPod(pods, executor.Runtime.Order)
+	_, orderSpan := tracing.Tracer().Start(ctx, "cascade.order")
+	reorderPod(pods, executor.Runtime.Order, rng)
+	orderSpan.SetAttributes(tracing.OrderingKey.String(executor.Runtime.Order.String()))
+	orderSpan.End()
 
-	return pods, nil
+	return pods
 }
 
-// Returns the list of pods which qualify the targeting critera.
-// Excludes terminating pods from Candidate List
-func (executor *Executor) SelectCandidatePods(ctx context.Context) ([]v1.Pod, error) {
+// Guard keeping pods which don't qualify the targeting criteria out of the candidates
+type podGuard struct {
+	// Reported onto plans for the pods it skips
+	Name   string
+	Filter func(pods []v1.Pod) ([]v1.Pod, error)
+}
+
+// Returns the guards candidate pods go through, in order
+func (executor *Executor) podGuards() []podGuard {
+	target := executor.Target
+	return []podGuard{
+		{Name: "namespaces", Filter: func(pods []v1.Pod) ([]v1.Pod, error) {
+			return filterByNamespaces(pods, target.Namespaces)
+		}},
+		{Name: "includedNodeNames", Filter: func(pods []v1.Pod) ([]v1.Pod, error) {
+			return includePodsByNodeName(pods, target.IncludedNodeNames), nil
+		}},
+		{Name: "includedPodNames", Filter: func(pods []v1.Pod) ([]v1.Pod, error) {
+			return includePodsByPodName(pods, target.IncludedPodNames), nil
+		}},
+		{Name: "excludedPodNames", Filter: func(pods []v1.Pod) ([]v1.Pod, error) {
+			return excludePodsByPodName(pods, target.ExcludedPodNames), nil
+		}},
+		{Name: "terminating", Filter: func(pods []v1.Pod) ([]v1.Pod, error) {
+			return filterTerminatingPods(pods), nil
+		}},
+	}
+}
+
+// Lists the pods within the targeted namespaces, prior to any guard
+func (executor *Executor) listPods(ctx context.Context) ([]v1.Pod, error) {
+	if executor.Cache != nil {
+		return executor.Cache.List(), nil
+	}
+
 	listOptions := metav1.ListOptions{LabelSelector: ""} // get all labels
 
-	allPods, err := executor.Client.CoreV1().Pods(executor.Target.Namespaces.String()).List(ctx, listOptions)
+	allPods, err := executor.Client.CoreV1().Pods(listNamespace(executor.Target.Namespaces)).List(ctx, listOptions)
 	if err != nil {
 		return nil, err
 	}
+	return allPods.Items, nil
+}
 
-	filteredPods, err := filterByNamespaces(allPods.Items, executor.Target.Namespaces)
+// Narrows the pods down through the indexes of the pod cache, falling back onto a list without one
+func (executor *Executor) candidatePods(ctx context.Context) ([]v1.Pod, error) {
+	if executor.Cache != nil {
+		return executor.Cache.Select(executor.Target)
+	}
+	return executor.listPods(ctx)
+}
+
+// Returns the list of pods which qualify the targeting critera.
+// Excludes terminating pods from Candidate List
+func (executor *Executor) SelectCandidatePods(ctx context.Context) ([]v1.Pod, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade.candidates")
+	defer span.End()
+
+	filteredPods, err := executor.candidatePods(ctx)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
-	filteredPods = includePodsByNodeName(filteredPods, executor.Target.IncludedNodeNames)
-	filteredPods = includePodsByPodName(filteredPods, executor.Target.IncludedPodNames)
-	filteredPods = excludePodsByPodName(filteredPods, executor.Target.ExcludedPodNames)
-	filteredPods = filterTerminatingPods(filteredPods)
+	for _, guard := range executor.podGuards() {
+		filteredPods, err = guard.Filter(filteredPods)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+	}
 
-	executor.Logger.Info(fmt.Sprintf("Filtering down to %d Candidates", len(filteredPods)))
+	span.SetAttributes(tracing.CandidatesKey.Int(len(filteredPods)))
+	executor.Logger.Info(fmt.Sprintf("Filtering down to %d Candidates", len(filteredPods)),
+		log.Event(log.EventCandidatesSelected),
+		zap.Int("candidates", len(filteredPods)),
+	)
 	return filteredPods, nil
 }
 
 // Trigger Pod Deletion based on Termination Strategy
 func (executor *Executor) DeletePod(pod v1.Pod, ctx context.Context) error {
+	ctx, span := tracing.Tracer().Start(ctx, "cascade."+executor.Runtime.Mode.String())
+	defer span.End()
+	span.SetAttributes(
+		tracing.ModeKey.String(executor.Runtime.Mode.String()),
+		tracing.PodKey.String(pod.Name),
+		tracing.NamespaceKey.String(pod.Namespace),
+		tracing.NodeKey.String(pod.Spec.NodeName),
+	)
 
 	opts := metav1.DeleteOptions{
 		GracePeriodSeconds: &executor.Runtime.Grace,
 	}
 	var err error
+	var event string
 
 	switch executor.Runtime.Mode {
 	case DryRun:
 		executor.Logger.Info("Terminating as per Dry Run Strategy")
-		time.Sleep(time.Duration(executor.Runtime.Grace)) // To mock grace period
-		err = nil
+		event = log.EventPodSkipped
 	case Evict:
 		executor.Logger.Info("Terminating as per Eviction Strategy")
 		err = executor.Client.CoreV1().Pods(pod.Namespace).Evict(ctx, &policyv1.Eviction{
 			ObjectMeta:    metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
 			DeleteOptions: &opts,
 		})
+		event = log.EventPodEvicted
 	default:
 		executor.Logger.Info("Terminating as per Deletion Strategy")
 		err = executor.Client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, opts)
+		event = log.EventPodDeleted
 	}
 
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 
+	executor.Logger.Info("Pod terminated", log.Event(event),
+		zap.String("pod", pod.Name),
+		zap.String("namespace", pod.Namespace),
+	)
+
 	ref, err := reference.GetReference(scheme.Scheme, &pod)
 	if err != nil {
 		return err
@@ -0,0 +1,121 @@
+package k8x
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Returns pods spread across two namespaces, in the order the API Server could list them in
+func testPods(n int) []v1.Pod {
+	pods := make([]v1.Pod, n)
+	for i := range pods {
+		pods[i] = v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: fmt.Sprintf("ns-%d", i%2),
+			Name:      fmt.Sprintf("pod-%02d", i),
+		}}
+	}
+	return pods
+}
+
+func podNames(pods []v1.Pod) []string {
+	names := make([]string, len(pods))
+	for i, pod := range pods {
+		names[i] = pod.Namespace + "/" + pod.Name
+	}
+	return names
+}
+
+func testExecutor(seed int64, ratio float64, order OrderingStrategy) *Executor {
+	return &Executor{
+		Runtime: &RuntimeConfig{Seed: seed, Ratio: ratio, Order: order},
+		Logger:  zap.NewNop(),
+	}
+}
+
+func TestSampleReplay(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  int64
+		tick  uint64
+		ratio float64
+		order OrderingStrategy
+	}{
+		{name: "random ordering", seed: 42, tick: 0, ratio: 0.5, order: Random},
+		{name: "later tick", seed: 42, tick: 7, ratio: 0.5, order: Random},
+		{name: "negative seed", seed: -3, tick: 1, ratio: 0.3, order: Random},
+		{name: "default ordering", seed: 1, tick: 2, ratio: 0.25, order: Default},
+		{name: "every pod", seed: 9, tick: 0, ratio: 1, order: Random},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			first := testExecutor(tt.seed, tt.ratio, tt.order).sample(ctx, testPods(20), tt.tick)
+
+			// Candidates listed in another order draw the same victims
+			reversed := testPods(20)
+			slices.Reverse(reversed)
+			second := testExecutor(tt.seed, tt.ratio, tt.order).sample(ctx, reversed, tt.tick)
+
+			if got, want := podNames(second), podNames(first); !slices.Equal(got, want) {
+				t.Fatalf("replayed sample = %v, want %v", got, want)
+			}
+			if want := testExecutor(tt.seed, tt.ratio, tt.order).Runtime.Victims(20); len(first) != want {
+				t.Fatalf("sampled %d victims, want %d", len(first), want)
+			}
+		})
+	}
+}
+
+func TestSampleVariesAcrossSeedsAndTicks(t *testing.T) {
+	ctx := context.Background()
+	base := podNames(testExecutor(42, 0.5, Random).sample(ctx, testPods(20), 0))
+
+	otherSeed := podNames(testExecutor(43, 0.5, Random).sample(ctx, testPods(20), 0))
+	if slices.Equal(base, otherSeed) {
+		t.Errorf("seeds 42 and 43 sampled the same victims %v", base)
+	}
+
+	otherTick := podNames(testExecutor(42, 0.5, Random).sample(ctx, testPods(20), 1))
+	if slices.Equal(base, otherTick) {
+		t.Errorf("ticks 0 and 1 sampled the same victims %v", base)
+	}
+}
+
+func TestSelectionRand(t *testing.T) {
+	tests := []struct {
+		name string
+		seed int64
+		tick uint64
+	}{
+		{name: "zero", seed: 0, tick: 0},
+		{name: "positive seed", seed: 1234, tick: 3},
+		{name: "negative seed", seed: -1234, tick: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := selectionRand(tt.seed, tt.tick), selectionRand(tt.seed, tt.tick)
+			for i := 0; i < 10; i++ {
+				if x, y := a.Uint64(), b.Uint64(); x != y {
+					t.Fatalf("draw %d = %d and %d, want equal draws for the same seed and tick", i, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestNextTick(t *testing.T) {
+	executor := testExecutor(0, 0, Random)
+	for want := uint64(0); want < 3; want++ {
+		if got := executor.nextTick(); got != want {
+			t.Fatalf("nextTick() = %d, want %d", got, want)
+		}
+	}
+}
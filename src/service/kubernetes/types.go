@@ -2,6 +2,9 @@ package k8x
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
 	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
@@ -25,10 +28,30 @@ type ClusterConfig struct {
 	Kubeconfig string `json:"kubeconfig" yaml:"kubeconfig"`
 	// The address of the Kubernetes cluster to target, if none looks under $HOME/.kube L
 	Master string `json:"master" yaml:"master"`
+	// Context to use from the kubeconfig, defaults to its current context
+	Context string `json:"context" yaml:"context"`
 	// Listens this endpoint for healtcheck
 	Healthcheck string `json:"healthcheck" yaml:"healthcheck"`
 	// Origin of the client
 	Origin string `json:"origin" yaml:"origin" default:"host"`
+	// Address of the API Server, overrides the one found in the credentials
+	Server string `json:"server" yaml:"server"`
+	// Credentials of a registered cluster, takes precedence over the kubeconfig path
+	Credentials *ClusterCredentials `json:"-" yaml:"-"`
+}
+
+// Credentials for reaching a registered cluster, stored encrypted at rest
+type ClusterCredentials struct {
+	// Contents of a kubeconfig file
+	Kubeconfig []byte `json:"kubeconfig,omitempty"`
+	// Context to use from the kubeconfig, defaults to its current context
+	Context string `json:"context,omitempty"`
+	// Bearer token, used when no kubeconfig is set
+	Token string `json:"token,omitempty"`
+	// PEM encoded certificate authority of the API Server
+	CAData []byte `json:"caData,omitempty"`
+	// Skips verifying the API Server's certificate
+	Insecure bool `json:"insecure,omitempty"`
 }
 
 // Determines the Pod Termination Strategy
@@ -41,17 +64,28 @@ const (
 )
 
 // ParseExecutionMode converts a string representation of ExecutionMode to its enum value.
-func ParseExecutionMode(modeStr string) ExecutionMode {
+func ParseExecutionMode(modeStr string) (ExecutionMode, error) {
 	switch modeStr {
 	case "delete":
-		return Delete
+		return Delete, nil
 	case "dry-run":
-		return DryRun
+		return DryRun, nil
 	case "evict":
-		return Evict
+		return Evict, nil
+	default:
+		return Delete, fmt.Errorf("unknown mode %q, expected delete, dry-run or evict", modeStr)
+	}
+}
+
+// Returns the string representation of the ExecutionMode
+func (mode ExecutionMode) String() string {
+	switch mode {
+	case DryRun:
+		return "dry-run"
+	case Evict:
+		return "evict"
 	default:
-		// Default to Delete
-		return Delete
+		return "delete"
 	}
 }
 
@@ -67,21 +101,71 @@ const (
 )
 
 // ParseOrderingStrategy converts a string representation of OrderingStrategy to its enum value.
-func ParseOrderingStrategy(orderingStr string) OrderingStrategy {
+func ParseOrderingStrategy(orderingStr string) (OrderingStrategy, error) {
 	switch orderingStr {
 	case "random":
-		return Random
+		return Random, nil
 	case "default":
-		return Default
+		return Default, nil
 	case "cost":
-		return Cost
+		return Cost, nil
 	case "youngest":
-		return Youngest
+		return Youngest, nil
 	case "oldest":
-		return Oldest
+		return Oldest, nil
+	default:
+		return Random, fmt.Errorf("unknown ordering %q, expected random, default, cost, youngest or oldest", orderingStr)
+	}
+}
+
+// Returns the string representation of the OrderingStrategy
+func (strategy OrderingStrategy) String() string {
+	switch strategy {
+	case Default:
+		return "default"
+	case Cost:
+		return "cost"
+	case Youngest:
+		return "youngest"
+	case Oldest:
+		return "oldest"
+	default:
+		return "random"
+	}
+}
+
+// Determines how the ratio of candidates is rounded onto a number of victims
+type RoundingMode int
+
+const (
+	Floor RoundingMode = iota // Rounds down, small candidate sets may yield no victim
+	Ceil                      // Rounds up, any candidate set yields at least one victim
+	Round                     // Rounds to the nearest number of victims
+)
+
+// ParseRoundingMode converts a string representation of RoundingMode to its enum value.
+func ParseRoundingMode(roundingStr string) (RoundingMode, error) {
+	switch roundingStr {
+	case "floor":
+		return Floor, nil
+	case "ceil":
+		return Ceil, nil
+	case "round":
+		return Round, nil
+	default:
+		return Floor, fmt.Errorf("unknown rounding %q, expected floor, ceil or round", roundingStr)
+	}
+}
+
+// Returns the string representation of the RoundingMode
+func (mode RoundingMode) String() string {
+	switch mode {
+	case Ceil:
+		return "ceil"
+	case Round:
+		return "round"
 	default:
-		// Default to Random
-		return Random
+		return "floor"
 	}
 }
 
@@ -89,6 +173,15 @@ func ParseOrderingStrategy(orderingStr string) OrderingStrategy {
 type RuntimeConfig struct {
 	// Interval between killing pods
 	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Distribution intervals are drawn from
+	Distribution IntervalDistribution `json:"distribution" yaml:"distribution"`
+	// Bounds on drawn intervals, a zero maximum leaves exponential intervals unbounded
+	MinInterval time.Duration `json:"minInterval,omitempty" yaml:"minInterval"`
+	MaxInterval time.Duration `json:"maxInterval,omitempty" yaml:"maxInterval"`
+	// Spreads every interval by up to ±IntervalJitter of itself, within [0, 1]
+	IntervalJitter float64 `json:"intervalJitter,omitempty" yaml:"intervalJitter"`
+	// Delay before the first tick
+	InitialDelay time.Duration `json:"initialDelay,omitempty" yaml:"initialDelay"`
 	// Grace Time after which pods are terminated
 	Grace int64 `json:"grace" yaml:"grace"`
 	// Ratio of pods to kill
@@ -97,7 +190,58 @@ type RuntimeConfig struct {
 	Mode ExecutionMode `json:"mode" yaml:"mode"`
 	// Pod Ordering strategy
 	Order OrderingStrategy `json:"ordering" yaml:"ordering"`
+	// Seeds victim selection, the same seed draws the same victims out of the same candidates
+	Seed int64 `json:"seed" yaml:"seed"`
+	// Absolute number of victims, takes precedence over the ratio if set
+	Count int `json:"count,omitempty" yaml:"count"`
+	// Bounds on the number of victims, a zero maximum leaves it unbounded
+	MinVictims int `json:"minVictims,omitempty" yaml:"minVictims"`
+	MaxVictims int `json:"maxVictims,omitempty" yaml:"maxVictims"`
+	// Rounding applied onto the ratio
+	Rounding RoundingMode `json:"rounding" yaml:"rounding"`
+	// Victims acted upon at once within a tick
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+	// Actions started per second, zero leaves them unlimited
+	RateLimit float64 `json:"rateLimit,omitempty" yaml:"rateLimit"`
+	// Delay between starting actions on consecutive victims, extended by a random jitter up to Jitter
+	Stagger time.Duration `json:"stagger,omitempty" yaml:"stagger"`
+	Jitter  time.Duration `json:"jitter,omitempty" yaml:"jitter"`
+}
+
+// Returns the delay before acting upon the next victim
+func (rc *RuntimeConfig) delay() time.Duration {
+	if rc.Jitter <= 0 {
+		return rc.Stagger
+	}
+	return rc.Stagger + rand.N(rc.Jitter)
+}
+
+// Ratios such as 0.07 aren't exact in binary, products this close to a whole number of victims are rounded onto it
+const victimsEpsilon = 1e-9
+
+// Returns the number of victims to sample out of the candidates, never more than there are candidates
+func (rc *RuntimeConfig) Victims(candidates int) int {
+	count := rc.Count
+	if count == 0 {
+		exact := float64(candidates) * rc.Ratio
+		switch rc.Rounding {
+		case Ceil:
+			count = int(math.Ceil(exact - victimsEpsilon))
+		case Round:
+			count = int(math.Round(exact))
+		default:
+			count = int(math.Floor(exact + victimsEpsilon))
+		}
+	}
+
+	count = max(count, rc.MinVictims)
+	if rc.MaxVictims > 0 {
+		count = min(count, rc.MaxVictims)
+	}
+	return min(max(count, 0), candidates)
 }
 
 var podNotFound = "pod not found"
 var errPodNotFound = errors.New(podNotFound)
+
+var errMissingServer = errors.New("cluster has no api server address")
@@ -0,0 +1,128 @@
+package k8x
+
+import "testing"
+
+func TestVictims(t *testing.T) {
+	tests := []struct {
+		name       string
+		rc         RuntimeConfig
+		candidates int
+		want       int
+	}{
+		// Rounding of the ratio
+		{name: "floor", rc: RuntimeConfig{Ratio: 0.5, Rounding: Floor}, candidates: 5, want: 2},
+		{name: "ceil", rc: RuntimeConfig{Ratio: 0.5, Rounding: Ceil}, candidates: 5, want: 3},
+		{name: "round half up", rc: RuntimeConfig{Ratio: 0.5, Rounding: Round}, candidates: 5, want: 3},
+		{name: "round down", rc: RuntimeConfig{Ratio: 0.3, Rounding: Round}, candidates: 5, want: 2},
+		{name: "floor of a small set", rc: RuntimeConfig{Ratio: 0.1, Rounding: Floor}, candidates: 3, want: 0},
+		{name: "ceil of a small set", rc: RuntimeConfig{Ratio: 0.1, Rounding: Ceil}, candidates: 3, want: 1},
+
+		// Ratios which aren't exact in binary
+		{name: "ceil of 7 percent", rc: RuntimeConfig{Ratio: 0.07, Rounding: Ceil}, candidates: 100, want: 7},
+		{name: "floor of 29 percent", rc: RuntimeConfig{Ratio: 0.29, Rounding: Floor}, candidates: 100, want: 29},
+		{name: "round of 7 percent", rc: RuntimeConfig{Ratio: 0.07, Rounding: Round}, candidates: 100, want: 7},
+		{name: "ceil just above a whole number", rc: RuntimeConfig{Ratio: 0.071, Rounding: Ceil}, candidates: 100, want: 8},
+
+		// Absolute counts take precedence over the ratio
+		{name: "count", rc: RuntimeConfig{Count: 4, Ratio: 0.9}, candidates: 10, want: 4},
+		{name: "count beyond the candidates", rc: RuntimeConfig{Count: 40}, candidates: 10, want: 10},
+
+		// Bounds
+		{name: "min victims", rc: RuntimeConfig{Ratio: 0.1, MinVictims: 3}, candidates: 10, want: 3},
+		{name: "max victims", rc: RuntimeConfig{Ratio: 0.9, MaxVictims: 2}, candidates: 10, want: 2},
+		{name: "zero max leaves it unbounded", rc: RuntimeConfig{Ratio: 1, MaxVictims: 0}, candidates: 10, want: 10},
+		{name: "min beyond the candidates", rc: RuntimeConfig{Ratio: 0.1, MinVictims: 20}, candidates: 10, want: 10},
+		{name: "max below min", rc: RuntimeConfig{Ratio: 0.5, MinVictims: 5, MaxVictims: 3}, candidates: 10, want: 3},
+		{name: "no candidates", rc: RuntimeConfig{Ratio: 1, MinVictims: 2}, candidates: 0, want: 0},
+		{name: "zero ratio", rc: RuntimeConfig{Ratio: 0, Rounding: Ceil}, candidates: 10, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rc.Victims(tt.candidates); got != tt.want {
+				t.Fatalf("Victims(%d) = %d, want %d", tt.candidates, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RoundingMode
+		wantErr bool
+	}{
+		{in: "floor", want: Floor},
+		{in: "ceil", want: Ceil},
+		{in: "round", want: Round},
+		{in: "", wantErr: true},
+		{in: "up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRoundingMode(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRoundingMode(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != tt.in) {
+				t.Fatalf("ParseRoundingMode(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExecutionMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ExecutionMode
+		wantErr bool
+	}{
+		{in: "delete", want: Delete},
+		{in: "dry-run", want: DryRun},
+		{in: "evict", want: Evict},
+		{in: "", wantErr: true},
+		{in: "Delete", wantErr: true},
+		{in: "dryrun", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseExecutionMode(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExecutionMode(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != tt.in) {
+				t.Fatalf("ParseExecutionMode(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOrderingStrategy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    OrderingStrategy
+		wantErr bool
+	}{
+		{in: "random", want: Random},
+		{in: "default", want: Default},
+		{in: "cost", want: Cost},
+		{in: "youngest", want: Youngest},
+		{in: "oldest", want: Oldest},
+		{in: "", wantErr: true},
+		{in: "newest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseOrderingStrategy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOrderingStrategy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || got.String() != tt.in) {
+				t.Fatalf("ParseOrderingStrategy(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
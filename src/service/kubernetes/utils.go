@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 )
@@ -17,14 +18,14 @@ import (
 // =====================
 
 func includePodsByNodeName(pods []v1.Pod, includedNodeNames string) (filteredPods []v1.Pod) {
-	includedPodNamesList := strings.Split(includedNodeNames, ",")
+	if includedNodeNames == "" {
+		return pods
+	}
 
 	var resultingPods []v1.Pod
 	for _, pod := range pods {
-		for _, podNameToInclude := range includedPodNamesList {
-			if strings.Contains(pod.Spec.NodeName, podNameToInclude) {
-				resultingPods = append(resultingPods, pod)
-			}
+		if containsAny(pod.Spec.NodeName, includedNodeNames) {
+			resultingPods = append(resultingPods, pod)
 		}
 	}
 
@@ -32,14 +33,14 @@ func includePodsByNodeName(pods []v1.Pod, includedNodeNames string) (filteredPod
 }
 
 func includePodsByPodName(pods []v1.Pod, includedPodNames string) (filteredPods []v1.Pod) {
-	includedPodNamesList := strings.Split(includedPodNames, ",")
+	if includedPodNames == "" {
+		return pods
+	}
 
 	var resultingPods []v1.Pod
 	for _, pod := range pods {
-		for _, podNameToInclude := range includedPodNamesList {
-			if strings.Contains(pod.ObjectMeta.Name, podNameToInclude) {
-				resultingPods = append(resultingPods, pod)
-			}
+		if containsAny(pod.ObjectMeta.Name, includedPodNames) {
+			resultingPods = append(resultingPods, pod)
 		}
 	}
 
@@ -47,29 +48,80 @@ func includePodsByPodName(pods []v1.Pod, includedPodNames string) (filteredPods
 }
 
 func excludePodsByPodName(pods []v1.Pod, excludedPodNames string) (filteredPods []v1.Pod) {
-	excludedPodNamesList := strings.Split(excludedPodNames, ",")
-
-	if len(excludedPodNamesList) == 1 && excludedPodNamesList[0] == "" {
+	if excludedPodNames == "" {
 		return pods
 	}
 
 	var resultingPods []v1.Pod
 	for _, pod := range pods {
-		for _, podNameToExclude := range excludedPodNamesList {
-			if !strings.Contains(pod.ObjectMeta.Name, podNameToExclude) {
-				resultingPods = append(resultingPods, pod)
-			}
+		if !containsAny(pod.ObjectMeta.Name, excludedPodNames) {
+			resultingPods = append(resultingPods, pod)
 		}
 	}
 
 	return resultingPods
 }
 
+// Checks whether the name contains any of the comma separated names
+func containsAny(name string, names string) bool {
+	for _, n := range strings.Split(names, ",") {
+		if strings.Contains(name, strings.TrimSpace(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the namespace pods are listed from, a selector including a single namespace lists it alone
+func listNamespace(namespaces labels.Selector) string {
+	requirements, _ := namespaces.Requirements()
+	if len(requirements) == 1 && requirements[0].Operator() == selection.Exists {
+		return requirements[0].Key()
+	}
+	return metav1.NamespaceAll
+}
+
 func filterByNamespaces(pods []v1.Pod, namespaces labels.Selector) ([]v1.Pod, error) {
 	if namespaces.Empty() {
 		return pods, nil
 	}
 
+	matches, err := namespaceMatcher(namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredPods []v1.Pod
+	for _, pod := range pods {
+		if matches(pod.Namespace) {
+			filteredPods = append(filteredPods, pod)
+		}
+	}
+
+	return filteredPods, nil
+}
+
+// Checks that the namespace selector names namespaces to include or exclude,
+// namespaces are matched by name alone so no other operator applies
+func ValidateNamespaceSelector(namespaces labels.Selector) error {
+	requirements, _ := namespaces.Requirements()
+	for _, req := range requirements {
+		switch req.Operator() {
+		case selection.Exists, selection.DoesNotExist:
+		default:
+			return fmt.Errorf("unsupported operator: %s", req.Operator())
+		}
+	}
+	return nil
+}
+
+// Returns whether pods within a namespace qualify the namespace selector,
+// a pod qualifies if any namespace is included and none is excluded
+func namespaceMatcher(namespaces labels.Selector) (func(namespace string) bool, error) {
+	if err := ValidateNamespaceSelector(namespaces); err != nil {
+		return nil, err
+	}
+
 	requirements, _ := namespaces.Requirements()
 	var includeRequirements []labels.Requirement
 	var excludeRequirements []labels.Requirement
@@ -80,17 +132,13 @@ func filterByNamespaces(pods []v1.Pod, namespaces labels.Selector) ([]v1.Pod, er
 			includeRequirements = append(includeRequirements, req)
 		case selection.DoesNotExist:
 			excludeRequirements = append(excludeRequirements, req)
-		default:
-			return nil, fmt.Errorf("unsupported operator: %s", req.Operator())
 		}
 	}
 
-	var filteredPods []v1.Pod
-
-	for _, pod := range pods {
+	return func(namespace string) bool {
 		included := len(includeRequirements) == 0
 
-		selector := labels.Set{pod.Namespace: ""}
+		selector := labels.Set{namespace: ""}
 
 		for _, req := range includeRequirements {
 			if req.Matches(selector) {
@@ -106,12 +154,8 @@ func filterByNamespaces(pods []v1.Pod, namespaces labels.Selector) ([]v1.Pod, er
 			}
 		}
 
-		if included {
-			filteredPods = append(filteredPods, pod)
-		}
-	}
-
-	return filteredPods, nil
+		return included
+	}, nil
 }
 
 func filterTerminatingPods(pods []v1.Pod) []v1.Pod {
@@ -125,14 +169,27 @@ func filterTerminatingPods(pods []v1.Pod) []v1.Pod {
 	return filteredList
 }
 
-func RandomPodSlice(pods []v1.Pod, percentageToKill float64) []v1.Pod {
-	count := int(float64(len(pods)) * percentageToKill)
-
-	rand.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
+func RandomPodSlice(pods []v1.Pod, count int, rng *rand.Rand) []v1.Pod {
+	rng.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
 	res := pods[0:count]
 	return res
 }
 
+// Sorts pods by namespace and name, so sampling doesn't depend upon the order the API Server listed them in
+func canonicalOrdering(pods []v1.Pod) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+}
+
+// Returns the source of randomness for a tick, the same seed and tick always draw the same victims
+func selectionRand(seed int64, tick uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), tick))
+}
+
 // Calculates the Pod Deletion Cost
 // Reference: https://kubernetes.io/docs/concepts/workloads/controllers/replicaset/#pod-deletion-cost
 func getPodDeletionCost(pod v1.Pod) int32 {
@@ -152,10 +209,10 @@ func getPodDeletionCost(pod v1.Pod) int32 {
 // ========================
 
 // Reorder Pods based on the ordering strategy
-func reorderPod(pods []v1.Pod, strategy OrderingStrategy) {
+func reorderPod(pods []v1.Pod, strategy OrderingStrategy, rng *rand.Rand) {
 	switch strategy {
 	case Random:
-		randomOrdering(pods)
+		randomOrdering(pods, rng)
 	case Default:
 		defaultOrdering(pods)
 	case Cost:
@@ -165,7 +222,7 @@ func reorderPod(pods []v1.Pod, strategy OrderingStrategy) {
 	case Oldest:
 		oldestFirstOrdering(pods)
 	default:
-		randomOrdering(pods)
+		randomOrdering(pods, rng)
 	}
 }
 
@@ -173,8 +230,8 @@ func reorderPod(pods []v1.Pod, strategy OrderingStrategy) {
 func defaultOrdering([]v1.Pod) {}
 
 // Randomizes the ordering
-func randomOrdering(pods []v1.Pod) {
-	rand.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
+func randomOrdering(pods []v1.Pod, rng *rand.Rand) {
+	rng.Shuffle(len(pods), func(i, j int) { pods[i], pods[j] = pods[j], pods[i] })
 }
 
 // Older pods rank higher
@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/wizenheimer/cascade/internal/logger"
+	"github.com/wizenheimer/cascade/internal/tracing"
+	k8x "github.com/wizenheimer/cascade/service/kubernetes"
+	"go.uber.org/zap"
+)
+
+// Cluster a session acts upon
+type Target struct {
+	// Registered cluster ID or kube context, identifies the cluster in reports and logs
+	Name   string
+	Config *k8x.ClusterConfig
+}
+
+// Determines what a session runs, and where
+type Config struct {
+	// Identifies the session within logs and traces
+	ID       string
+	Scenario string
+	Targets  []Target
+	Target   *k8x.TargetConfig
+	Runtime  *k8x.RuntimeConfig
+	FanOut   *k8x.FanOutConfig
+	// Attached onto the entry logged as the session starts
+	Fields []zap.Field
+}
+
+// Invoked along the lifecycle of a session, hooks left nil are skipped
+type Hooks struct {
+	// Invoked as the session starts running
+	OnStart func(ctx context.Context, session *Session)
+	// Invoked whenever the status of a cluster changes, persists progress. Must be safe for concurrent use
+	OnUpdate func(ctx context.Context, session *Session, report k8x.ClusterReport)
+	// Invoked once every cluster is done, even once cancelled. The context outlives the cancellation
+	OnEnd func(ctx context.Context, session *Session, report *k8x.FanOutReport)
+}
+
+// Chaos session running a scenario across one or more clusters
+type Session struct {
+	Config Config
+	Hooks  Hooks
+	// Session logger, entries are forwarded onto every sink
+	Logger *zap.Logger
+
+	fanout *k8x.FanOut
+	sinks  *dispatcher
+
+	mu       sync.Mutex
+	status   string
+	clusters []k8x.ClusterReport
+	report   *k8x.FanOutReport
+	cancel   context.CancelFunc
+	// Set once cancelled ahead of running
+	cancelled bool
+	done      chan struct{}
+}
+
+// Prepares a session, creating an executor for every target. Nothing acts upon the clusters until the session runs
+func New(cfg Config, logger *zap.Logger, hooks Hooks, sinks ...Sink) (*Session, error) {
+	session := &Session{
+		Config:   cfg,
+		Hooks:    hooks,
+		status:   k8x.ClusterPending,
+		clusters: make([]k8x.ClusterReport, len(cfg.Targets)),
+		done:     make(chan struct{}),
+	}
+
+	// Tee entries onto the sinks ahead of attaching fields, so the sinks receive them
+	if len(sinks) > 0 {
+		session.sinks = newDispatcher(sinks)
+		logger = log.WithChannel(logger, session.sinks.entries)
+	}
+	logger = logger.With(log.Session(cfg.ID))
+	session.Logger = logger
+
+	targets := make([]k8x.FanOutTarget, 0, len(cfg.Targets))
+	release := func() {
+		for _, target := range targets {
+			target.Executor.Close()
+		}
+		session.sinks.stop()
+	}
+	for i, target := range cfg.Targets {
+		executor, err := k8x.CreateExecutor(target.Config, cfg.Target, cfg.Runtime, logger.With(zap.String("cluster", target.Name)))
+		if err != nil {
+			release()
+			return nil, err
+		}
+		targets = append(targets, k8x.FanOutTarget{Name: target.Name, Executor: executor})
+		session.clusters[i] = k8x.ClusterReport{Cluster: target.Name, Status: k8x.ClusterPending}
+	}
+
+	fanout, err := k8x.CreateFanOut(targets, cfg.FanOut, logger)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	fanout.OnUpdate = session.update
+	session.fanout = fanout
+
+	return session, nil
+}
+
+// Runs the session until every cluster is done or the context is cancelled, returns the combined report.
+// A session runs at most once
+func (session *Session) Run(ctx context.Context) *k8x.FanOutReport {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session.mu.Lock()
+	session.cancel = cancel
+	session.status = k8x.ClusterRunning
+	if session.cancelled {
+		cancel()
+	}
+	session.mu.Unlock()
+
+	ctx, span := tracing.StartSession(ctx, session.Config.ID, session.Config.Scenario)
+	defer span.End()
+
+	if session.Hooks.OnStart != nil {
+		session.Hooks.OnStart(ctx, session)
+	}
+
+	names := make([]string, len(session.Config.Targets))
+	for i, target := range session.Config.Targets {
+		names[i] = target.Name
+	}
+	fields := append([]zap.Field{
+		log.Event(log.EventSessionStarted),
+		zap.String("scenario", session.Config.Scenario),
+		zap.Strings("clusters", names),
+		zap.String("fanout", session.Config.FanOut.Strategy.String()),
+		zap.Int64("seed", session.Config.Runtime.Seed),
+	}, session.Config.Fields...)
+	session.Logger.Info("Chaos Session Triggered", fields...)
+
+	report := session.fanout.Run(ctx)
+	session.Logger.Info("Session report", log.Event(log.EventSessionReport), zap.Any("report", report))
+	session.Logger.Info("Chaos Session completed", log.Event(log.EventSessionEnded), zap.String("status", report.Status))
+
+	if session.Hooks.OnEnd != nil {
+		session.Hooks.OnEnd(context.WithoutCancel(ctx), session, report)
+	}
+
+	session.mu.Lock()
+	session.status = report.Status
+	session.report = report
+	session.mu.Unlock()
+
+	// Flush the remaining entries onto the sinks before reporting the session as done
+	session.Release()
+	close(session.done)
+
+	return report
+}
+
+// Releases the executors of the session and stops its sinks, for sessions which won't run.
+// Sessions release them once they have run
+func (session *Session) Release() {
+	session.fanout.Close()
+	session.sinks.stop()
+}
+
+// Runs the session in the background, Wait returns its report
+func (session *Session) Start(ctx context.Context) {
+	go session.Run(ctx)
+}
+
+// Cancels the session, clusters wind down their current round before it ends
+func (session *Session) Cancel() {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.cancelled = true
+	if session.cancel != nil {
+		session.cancel()
+	}
+}
+
+// Closed once the session has ended and its sinks are flushed
+func (session *Session) Done() <-chan struct{} {
+	return session.done
+}
+
+// Blocks until the session has ended, returns its combined report
+func (session *Session) Wait() *k8x.FanOutReport {
+	<-session.done
+	return session.Report()
+}
+
+// Returns the status of the session, one of pending, running, completed, failed or cancelled
+func (session *Session) Status() string {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.status
+}
+
+// Returns the combined report of the session, live while it runs
+func (session *Session) Report() *k8x.FanOutReport {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.report != nil {
+		return session.report
+	}
+	clusters := make([]k8x.ClusterReport, len(session.clusters))
+	copy(clusters, session.clusters)
+	report := k8x.Summarize(session.Config.FanOut.Strategy.String(), clusters)
+	report.Status = session.status
+	return report
+}
+
+// Records the status of a cluster, before handing it onto the update hook
+func (session *Session) update(report k8x.ClusterReport) {
+	session.mu.Lock()
+	for i := range session.clusters {
+		if session.clusters[i].Cluster == report.Cluster {
+			session.clusters[i] = report
+		}
+	}
+	session.mu.Unlock()
+
+	if session.Hooks.OnUpdate != nil {
+		session.Hooks.OnUpdate(context.Background(), session, report)
+	}
+}
@@ -0,0 +1,77 @@
+package runner
+
+import (
+	"sync"
+
+	log "github.com/wizenheimer/cascade/internal/logger"
+)
+
+// Receives the log entries of a session, such as an event stream or a terminal
+type Sink interface {
+	Write(entry log.LogEntry) error
+}
+
+// Adapts a function onto a sink
+type SinkFunc func(entry log.LogEntry) error
+
+func (f SinkFunc) Write(entry log.LogEntry) error {
+	return f(entry)
+}
+
+// Forwards log entries onto the sinks from a single goroutine, so sinks needn't be safe for concurrent use.
+// Sinks failing to write are dropped
+type dispatcher struct {
+	entries chan log.LogEntry
+	sinks   []Sink
+	quit    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+func newDispatcher(sinks []Sink) *dispatcher {
+	d := &dispatcher{
+		entries: make(chan log.LogEntry, 100),
+		sinks:   sinks,
+		quit:    make(chan struct{}),
+	}
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case entry := <-d.entries:
+			d.write(entry)
+		case <-d.quit:
+			// Flush entries logged before stopping, later ones are dropped
+			for len(d.entries) > 0 {
+				d.write(<-d.entries)
+			}
+			return
+		}
+	}
+}
+
+func (d *dispatcher) write(entry log.LogEntry) {
+	sinks := d.sinks[:0]
+	for _, sink := range d.sinks {
+		if err := sink.Write(entry); err == nil {
+			sinks = append(sinks, sink)
+		}
+	}
+	d.sinks = sinks
+}
+
+// Stops forwarding entries once the pending ones are flushed
+func (d *dispatcher) stop() {
+	if d == nil {
+		return
+	}
+	d.once.Do(func() {
+		close(d.quit)
+		d.wg.Wait()
+	})
+}